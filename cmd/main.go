@@ -4,23 +4,66 @@ import (
 	"MyCoinApp/config"
 	"MyCoinApp/internal/api"
 	"MyCoinApp/internal/blockchain"
+	"MyCoinApp/internal/events"
+	"MyCoinApp/internal/p2p"
+	"MyCoinApp/internal/pool"
+	"MyCoinApp/internal/wallet"
 	"fmt"
 	"log"
 )
 
 func main() {
-	cfg := config.LoadConfig()
-	fmt.Println("Config loaded:", cfg)
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Println("Config error:", err)
+		return
+	}
+	log.Printf("Config loaded: http %s, grpc %s, p2p %s, chain %s", cfg.Port, cfg.GRPCPort, cfg.P2PPort, cfg.ChainID)
 
 	if err := cfg.Validate(); err != nil {
 		fmt.Println("Config validation error:", err)
 		return
 	}
 
-	bc := blockchain.NewBlockchain()
+	// Chỉ node giữ khóa của ví faucet mới cấp coin cho ví mới và tự tạo block
+	var faucetWallet *wallet.Wallet
+	if cfg.FaucetPassphrase != "" {
+		faucetWallet = wallet.NewWalletFromPassphrase(cfg.FaucetPassphrase)
+		log.Printf("Faucet address: %s (peers join with MYCOIN_GENESIS_FAUCET=%s)", faucetWallet.Address, faucetWallet.Address)
+	} else {
+		log.Printf("Genesis faucet: %s (faucet key not held by this node)", cfg.GenesisFaucet)
+	}
+
+	// Balances và StakingPool được dựng lại bằng cách replay chain từ genesis
+	txPool := pool.NewTransactionPool(cfg.MempoolMaxSize, cfg.MempoolMaxPerSender)
+	bus := events.NewBus()
+	bc, err := blockchain.NewBlockchain(cfg.GenesisFaucet, cfg.FeePolicy, txPool, bus)
+	if err != nil {
+		log.Fatalf("Failed to load blockchain: %v", err)
+	}
 	log.Printf("Blockchain initialized with %d blocks", len(bc.Chain))
 
-	srv := api.NewServer(bc, cfg)
+	if faucetWallet != nil {
+		if err := bc.PayLegacyBalances(faucetWallet); err != nil {
+			log.Fatalf("Failed to pay legacy balances: %v", err)
+		}
+		if cfg.ProduceBlocks {
+			bc.StartBlockProducer(faucetWallet, cfg.BlockProducerInterval)
+		}
+	}
+	bc.StartMempoolSweeper(cfg.MempoolSweepInterval, cfg.MempoolTTL)
+
+	network := p2p.NewNode(bc, bus, cfg)
+	if err := network.Start(); err != nil {
+		log.Fatalf("Failed to start P2P node: %v", err)
+	}
+
+	srv := api.NewServer(bc, cfg, faucetWallet, bus, network)
+
+	go func() {
+		log.Printf("gRPC server starting on %s", cfg.GRPCPort)
+		log.Fatal(srv.StartGRPC())
+	}()
 
 	log.Printf("Server starting on %s", cfg.Port)
 	log.Fatal(srv.Start())
@@ -1,25 +1,200 @@
 package config
 
-import "fmt"
+import (
+	"MyCoinApp/internal/coin"
+	"MyCoinApp/internal/consensus"
+	"MyCoinApp/internal/wallet"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// faucetPassphraseFile lưu passphrase ngẫu nhiên của faucet do node tạo khi khởi tạo chain mới
+const faucetPassphraseFile = "faucet.passphrase"
 
 type Config struct {
-	Port                 string
-	InitialWalletBalance float64
+	Port string
+	// GRPCPort là địa chỉ lắng nghe của gRPC server (xem proto/node.proto)
+	GRPCPort             string
+	InitialWalletBalance coin.Amount
+	// AllowServerSideSigning cho phép các endpoint nhận private key qua HTTP
+	// (/transaction/send, /staking/stake, /staking/unstake, /blockchain/mine).
+	// Mặc định tắt để client chỉ gửi dữ liệu đã ký sẵn; bật bằng MYCOIN_ALLOW_SERVER_SIGNING=true
+	// khi chạy node cục bộ cho Web UI.
+	AllowServerSideSigning bool
+	// GenesisFaucet là địa chỉ ví faucet nhận toàn bộ coin ở genesis block và là validator
+	// đầu tiên của chain. Đây là tham số genesis: mọi node của cùng một chain phải dùng chung.
+	GenesisFaucet string
+	// FaucetPassphrase sinh khóa của ví GenesisFaucet, chỉ có trên node giữ ví faucet. Node
+	// có khóa cấp InitialWalletBalance cho ví mới và tự tạo block; node không có khóa để trống.
+	FaucetPassphrase string
+	// BlockProducerInterval là chu kỳ node kiểm tra để tự tạo block bằng ví faucet
+	BlockProducerInterval time.Duration
+	// FeePolicy chia phí giao dịch giữa validator, burn và treasury.
+	// Chain đã có block chỉ nạp được với đúng FeePolicy đã dùng để tạo block.
+	FeePolicy consensus.FeePolicy
+	// MempoolMaxSize là số giao dịch đang chờ tối đa; khi đầy, giao dịch phí thấp nhất bị loại
+	MempoolMaxSize int
+	// MempoolMaxPerSender là số giao dịch đang chờ tối đa của một địa chỉ
+	MempoolMaxPerSender int
+	// MempoolTTL là thời gian tối đa một giao dịch nằm trong pool trước khi bị dọn
+	MempoolTTL time.Duration
+	// MempoolSweepInterval là chu kỳ dọn giao dịch hết hạn hoặc quá MempoolTTL khỏi pool
+	MempoolSweepInterval time.Duration
+	// AdminToken bảo vệ các endpoint /api/admin (header X-Admin-Token).
+	// Để trống thì chỉ cho phép gọi từ localhost.
+	AdminToken string
+	// ChainID phân biệt các mạng MyCoin; node chỉ kết nối P2P với peer cùng ChainID và genesis block
+	ChainID string
+	// P2PPort là địa chỉ lắng nghe kết nối TCP từ các node khác (xem internal/p2p)
+	P2PPort string
+	// Peers là danh sách địa chỉ P2P tĩnh (host:port) mà node luôn giữ kết nối
+	Peers []string
+	// MaxPeers là số peer tối đa, tính cả kết nối đến, kết nối đi và kết nối đang handshake;
+	// kết nối vượt quá bị đóng trước handshake
+	MaxPeers int
+	// PeerBanDuration là thời gian cấm IP của peer gửi quá nhiều dữ liệu không hợp lệ
+	PeerBanDuration time.Duration
+	// ProduceBlocks bật block producer trên node giữ ví faucet. Node không có khóa faucet
+	// không bao giờ tạo block.
+	ProduceBlocks bool
 }
 
-func LoadConfig() *Config {
-	return &Config{
-		Port:                 ":8080",
-		InitialWalletBalance: 100.0,
+// LoadConfig đọc cấu hình từ biến môi trường. Trả về lỗi nếu không đọc hoặc tạo được
+// passphrase của faucet.
+func LoadConfig() (*Config, error) {
+	genesisFaucet := os.Getenv("MYCOIN_GENESIS_FAUCET")
+	faucetPassphrase, err := loadFaucetPassphrase(genesisFaucet == "")
+	if err != nil {
+		return nil, err
 	}
+	if genesisFaucet == "" {
+		genesisFaucet = wallet.NewWalletFromPassphrase(faucetPassphrase).Address
+	}
+
+	return &Config{
+		Port:                   envOr("MYCOIN_PORT", ":8080"),
+		GRPCPort:               envOr("MYCOIN_GRPC_PORT", ":9090"),
+		InitialWalletBalance:   100 * coin.Unit,
+		AllowServerSideSigning: os.Getenv("MYCOIN_ALLOW_SERVER_SIGNING") == "true",
+		GenesisFaucet:          genesisFaucet,
+		FaucetPassphrase:       faucetPassphrase,
+		BlockProducerInterval:  10 * time.Second,
+		FeePolicy:              consensus.DefaultFeePolicy(),
+		MempoolMaxSize:         5000,
+		MempoolMaxPerSender:    64,
+		MempoolTTL:             time.Hour,
+		MempoolSweepInterval:   30 * time.Second,
+		AdminToken:             os.Getenv("MYCOIN_ADMIN_TOKEN"),
+		ChainID:                envOr("MYCOIN_CHAIN_ID", "mycoin-demo"),
+		P2PPort:                envOr("MYCOIN_P2P_PORT", ":7070"),
+		Peers:                  SplitList(os.Getenv("MYCOIN_PEERS")),
+		MaxPeers:               32,
+		PeerBanDuration:        time.Hour,
+		ProduceBlocks:          os.Getenv("MYCOIN_PRODUCE_BLOCKS") != "false",
+	}, nil
 }
 
 func (c *Config) Validate() error {
 	if c.Port == "" {
 		return fmt.Errorf("port cannot be empty")
 	}
-	if c.InitialWalletBalance < 0 {
-		return fmt.Errorf("initial wallet balance cannot be negative")
+	if c.GRPCPort == "" {
+		return fmt.Errorf("grpc port cannot be empty")
+	}
+	if c.GRPCPort == c.Port {
+		return fmt.Errorf("grpc port must differ from the http port")
+	}
+	if c.P2PPort == "" {
+		return fmt.Errorf("p2p port cannot be empty")
+	}
+	if c.P2PPort == c.Port || c.P2PPort == c.GRPCPort {
+		return fmt.Errorf("p2p port must differ from the http and grpc ports")
+	}
+	if c.ChainID == "" {
+		return fmt.Errorf("chain id cannot be empty")
+	}
+	if c.MaxPeers <= 0 || c.PeerBanDuration <= 0 {
+		return fmt.Errorf("max peers and peer ban duration must be positive")
+	}
+	if c.GenesisFaucet == "" {
+		return fmt.Errorf("genesis faucet address cannot be empty")
+	}
+	if c.FaucetPassphrase != "" {
+		if address := wallet.NewWalletFromPassphrase(c.FaucetPassphrase).Address; address != c.GenesisFaucet {
+			return fmt.Errorf("faucet passphrase belongs to %s, not to the genesis faucet %s", address, c.GenesisFaucet)
+		}
+	}
+	if c.BlockProducerInterval <= 0 {
+		return fmt.Errorf("block producer interval must be positive")
+	}
+	if err := c.FeePolicy.Validate(); err != nil {
+		return err
+	}
+	if c.MempoolMaxSize <= 0 || c.MempoolMaxPerSender <= 0 {
+		return fmt.Errorf("mempool limits must be positive")
+	}
+	if c.MempoolTTL <= 0 || c.MempoolSweepInterval <= 0 {
+		return fmt.Errorf("mempool ttl and sweep interval must be positive")
 	}
 	return nil
 }
+
+// loadFaucetPassphrase đọc MYCOIN_FAUCET_PASSPHRASE, nếu không đặt thì đọc faucetPassphraseFile.
+// Khi không có cả hai và generate là true (node khởi tạo chain mới vì không có
+// MYCOIN_GENESIS_FAUCET), node tạo passphrase ngẫu nhiên và lưu vào faucetPassphraseFile để
+// không ai dựng lại được khóa của ví faucet. Khi generate là false, node không giữ ví faucet
+// và passphrase trả về rỗng.
+func loadFaucetPassphrase(generate bool) (string, error) {
+	if passphrase := os.Getenv("MYCOIN_FAUCET_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+
+	data, err := os.ReadFile(faucetPassphraseFile)
+	if err == nil {
+		if passphrase := strings.TrimSpace(string(data)); passphrase != "" {
+			return passphrase, nil
+		}
+		return "", fmt.Errorf("%s is empty", faucetPassphraseFile)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("read %s: %v", faucetPassphraseFile, err)
+	}
+	if !generate {
+		return "", nil
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	passphrase := hex.EncodeToString(secret)
+	if err := os.WriteFile(faucetPassphraseFile, []byte(passphrase+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("write %s: %v", faucetPassphraseFile, err)
+	}
+	return passphrase, nil
+}
+
+// envOr đọc biến môi trường name, trả về fallback nếu không đặt
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// SplitList tách danh sách phân cách bằng dấu phẩy (biến môi trường, query string),
+// bỏ khoảng trắng và phần tử rỗng
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
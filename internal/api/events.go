@@ -0,0 +1,138 @@
+package api
+
+import (
+	"MyCoinApp/config"
+	"MyCoinApp/internal/events"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// eventBuffer là số sự kiện tối đa chờ gửi cho một client; client chậm hơn bị ngắt
+	eventBuffer = 256
+	// eventKeepAlive là chu kỳ gửi tín hiệu giữ kết nối khi không có sự kiện
+	eventKeepAlive = 30 * time.Second
+	// eventWriteTimeout là thời gian tối đa để gửi một sự kiện qua WebSocket
+	eventWriteTimeout = 10 * time.Second
+)
+
+// parseEventFilter đọc query topics và addresses (phân tách bằng dấu phẩy)
+func parseEventFilter(c *gin.Context) (events.Filter, error) {
+	filter := events.Filter{
+		Topics:    make(map[events.Topic]bool),
+		Addresses: make(map[string]bool),
+	}
+
+	for _, value := range config.SplitList(c.Query("topics")) {
+		topic := events.Topic(value)
+		known := false
+		for _, t := range events.Topics {
+			if t == topic {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return filter, fmt.Errorf("unknown topic %q", value)
+		}
+		filter.Topics[topic] = true
+	}
+
+	for _, address := range config.SplitList(c.Query("addresses")) {
+		filter.Addresses[address] = true
+	}
+
+	return filter, nil
+}
+
+// streamEventsSSE gửi sự kiện dưới dạng Server-Sent Events, tên sự kiện là topic
+func (s *Server) streamEventsSSE(c *gin.Context) {
+	filter, err := parseEventFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription := s.events.Subscribe(filter, eventBuffer)
+	defer subscription.Close()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-subscription.Events:
+			if !ok {
+				// Client không đọc kịp và đã bị bus hủy
+				return false
+			}
+			c.SSEvent(string(event.Topic), event)
+			return true
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// streamEventsWebSocket gửi mỗi sự kiện là một message JSON qua WebSocket
+func (s *Server) streamEventsWebSocket(c *gin.Context) {
+	filter, err := parseEventFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	server := websocket.Server{
+		// Cho phép mọi Origin giống chính sách CORS của API
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			defer conn.Close()
+
+			subscription := s.events.Subscribe(filter, eventBuffer)
+			defer subscription.Close()
+
+			// Client không gửi dữ liệu; đọc để biết khi nào kết nối bị đóng
+			closed := make(chan struct{})
+			go func() {
+				io.Copy(io.Discard, conn)
+				close(closed)
+			}()
+
+			keepAlive := time.NewTicker(eventKeepAlive)
+			defer keepAlive.Stop()
+
+			for {
+				select {
+				case event, ok := <-subscription.Events:
+					if !ok {
+						return
+					}
+					conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+					if err := websocket.JSON.Send(conn, event); err != nil {
+						log.Printf("Event stream closed: %v", err)
+						return
+					}
+				case <-keepAlive.C:
+					conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+					if err := websocket.Message.Send(conn, "{}"); err != nil {
+						return
+					}
+				case <-closed:
+					return
+				}
+			}
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"MyCoinApp/internal/consensus"
+	"MyCoinApp/internal/events"
+	"MyCoinApp/internal/models"
+	"MyCoinApp/internal/pool"
+	"MyCoinApp/pkg/nodepb"
+	"context"
+	"log"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// nodeService cài đặt nodepb.NodeServer bằng cùng các hàm dịch vụ và method của
+// blockchain.Blockchain mà REST handler và JSON-RPC đang dùng
+type nodeService struct {
+	nodepb.UnimplementedNodeServer
+	server *Server
+}
+
+// StartGRPC chạy gRPC server trên Config.GRPCPort, chặn cho đến khi server dừng
+func (s *Server) StartGRPC() error {
+	listener, err := net.Listen("tcp", s.config.GRPCPort)
+	if err != nil {
+		return err
+	}
+
+	grpcServer := grpc.NewServer()
+	nodepb.RegisterNodeServer(grpcServer, &nodeService{server: s})
+	return grpcServer.Serve(listener)
+}
+
+func (n *nodeService) GetBalance(ctx context.Context, request *nodepb.GetBalanceRequest) (*nodepb.Balance, error) {
+	if request.Address == "" {
+		return nil, status.Error(codes.InvalidArgument, "address is required")
+	}
+	balance := n.server.balance(request.Address)
+	return &nodepb.Balance{
+		Address:   balance.Address,
+		Balance:   uint64(balance.Balance),
+		Available: uint64(balance.Available),
+	}, nil
+}
+
+func (n *nodeService) GetNonce(ctx context.Context, request *nodepb.GetNonceRequest) (*nodepb.Nonce, error) {
+	if request.Address == "" {
+		return nil, status.Error(codes.InvalidArgument, "address is required")
+	}
+	nonce := n.server.nonce(request.Address)
+	return &nodepb.Nonce{
+		Address:        nonce.Address,
+		Nonce:          nonce.Nonce,
+		ConfirmedNonce: nonce.ConfirmedNonce,
+	}, nil
+}
+
+func (n *nodeService) SendTransaction(ctx context.Context, request *nodepb.SendTransactionRequest) (*nodepb.SendTransactionResponse, error) {
+	tx, err := pool.DecodeTransaction(request.RawTransaction)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid transaction: %v", err)
+	}
+	if err := n.server.broadcast(tx); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &nodepb.SendTransactionResponse{Hash: tx.Hash}, nil
+}
+
+func (n *nodeService) GetTransaction(ctx context.Context, request *nodepb.GetTransactionRequest) (*nodepb.TransactionStatus, error) {
+	txStatus, found := n.server.blockchain.GetTransactionStatus(request.Hash)
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "transaction %s not found", request.Hash)
+	}
+
+	response := &nodepb.TransactionStatus{
+		Hash:        txStatus.Hash,
+		Status:      txStatus.Status,
+		Transaction: transactionProto(txStatus.Transaction),
+		Reason:      txStatus.Reason,
+	}
+	if receipt := txStatus.Receipt; receipt != nil {
+		response.Receipt = &nodepb.Receipt{
+			BlockIndex:    receipt.BlockIndex,
+			BlockHash:     receipt.BlockHash,
+			Position:      int32(receipt.Position),
+			Confirmations: receipt.Confirmations,
+		}
+	}
+	return response, nil
+}
+
+func (n *nodeService) GetBlock(ctx context.Context, request *nodepb.GetBlockRequest) (*nodepb.Block, error) {
+	var block *models.BlockResponse
+	var err error
+	switch selector := request.Block.(type) {
+	case *nodepb.GetBlockRequest_Index:
+		block, err = n.server.blockchain.GetBlockByIndex(selector.Index)
+	case *nodepb.GetBlockRequest_Hash:
+		block, err = n.server.blockchain.GetBlockByHash(selector.Hash)
+	default:
+		block, err = n.server.blockchain.GetBlockByIndex(n.server.blockchain.Height() - 1)
+	}
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return blockProto(block), nil
+}
+
+func (n *nodeService) GetValidators(ctx context.Context, request *nodepb.GetValidatorsRequest) (*nodepb.GetValidatorsResponse, error) {
+	validators := n.server.validators().Validators
+	response := &nodepb.GetValidatorsResponse{
+		Validators: make([]*nodepb.Validator, 0, len(validators)),
+	}
+	for _, validator := range validators {
+		response.Validators = append(response.Validators, validatorProto(validator))
+	}
+	return response, nil
+}
+
+// SubscribeBlocks gửi các block mới từ event bus; client không đọc kịp bị ngắt như
+// subscription WebSocket/SSE
+func (n *nodeService) SubscribeBlocks(request *nodepb.SubscribeBlocksRequest, stream nodepb.Node_SubscribeBlocksServer) error {
+	subscription := n.server.events.Subscribe(events.Filter{
+		Topics: map[events.Topic]bool{events.TopicBlock: true},
+	}, eventBuffer)
+	defer subscription.Close()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "subscriber fell behind")
+			}
+			block, ok := event.Data.(*models.BlockResponse)
+			if !ok {
+				continue
+			}
+			if err := stream.Send(blockProto(block)); err != nil {
+				log.Printf("Block subscription closed: %v", err)
+				return err
+			}
+		}
+	}
+}
+
+func transactionProto(tx *pool.Transaction) *nodepb.Transaction {
+	if tx == nil {
+		return nil
+	}
+
+	message := &nodepb.Transaction{
+		Version:          uint32(tx.Version),
+		Type:             string(tx.Type),
+		From:             tx.From,
+		To:               tx.To,
+		Amount:           uint64(tx.Amount),
+		Fee:              uint64(tx.Fee),
+		Nonce:            tx.Nonce,
+		Timestamp:        tx.Timestamp,
+		Hash:             tx.Hash,
+		PublicKey:        tx.PublicKey,
+		Signature:        tx.Signature,
+		ValidUntilHeight: tx.ValidUntilHeight,
+	}
+	if fees := tx.Fees; fees != nil {
+		message.Fees = &nodepb.FeeDistribution{
+			Collected:      uint64(fees.Collected),
+			Validator:      uint64(fees.Validator),
+			Burned:         uint64(fees.Burned),
+			Treasury:       fees.Treasury,
+			TreasuryAmount: uint64(fees.TreasuryAmount),
+		}
+	}
+	return message
+}
+
+func blockProto(block *models.BlockResponse) *nodepb.Block {
+	message := &nodepb.Block{
+		Version:            uint32(block.Version),
+		Index:              block.Index,
+		Timestamp:          block.Timestamp,
+		Hash:               block.Hash,
+		PreviousHash:       block.PreviousHash,
+		MerkleRoot:         block.MerkleRoot,
+		Validator:          block.Validator,
+		ValidatorPublicKey: block.ValidatorPublicKey,
+		Signature:          block.Signature,
+		TotalFees:          uint64(block.TotalFees),
+		Size:               int32(block.Size),
+		Confirmations:      block.Confirmations,
+		Transactions:       make([]*nodepb.Transaction, 0, len(block.Transactions)),
+	}
+	for _, tx := range block.Transactions {
+		message.Transactions = append(message.Transactions, transactionProto(tx))
+	}
+	return message
+}
+
+func validatorProto(validator *consensus.Validator) *nodepb.Validator {
+	return &nodepb.Validator{
+		Address:       validator.Address,
+		StakedAmount:  uint64(validator.StakedAmount),
+		LastBlockTime: validator.LastBlockTime,
+		SlashCount:    int32(validator.SlashCount),
+		IsActive:      validator.IsActive,
+		JoinTime:      validator.JoinTime,
+		TotalRewards:  uint64(validator.TotalRewards),
+	}
+}
@@ -3,12 +3,22 @@ package api
 import (
 	"MyCoinApp/config"
 	"MyCoinApp/internal/blockchain"
+	"MyCoinApp/internal/coin"
+	"MyCoinApp/internal/events"
 	"MyCoinApp/internal/models"
+	"MyCoinApp/internal/p2p"
 	"MyCoinApp/internal/pool"
 	"MyCoinApp/internal/wallet"
+	"crypto/subtle"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -16,15 +26,30 @@ import (
 
 type Server struct {
 	blockchain *blockchain.Blockchain
-	txPool     *pool.TransactionPool
 	config     *config.Config
+
+	// faucet cấp coin ban đầu cho ví mới bằng giao dịch ký bởi ví faucet; nil khi node
+	// không giữ khóa của ví faucet
+	faucet      *wallet.Wallet
+	faucetMutex sync.Mutex
+
+	// events là nguồn sự kiện cho các endpoint /api/events
+	events *events.Bus
+
+	// network là lớp P2P của node, cung cấp danh sách peer cho /api/network/peers
+	network *p2p.Node
+
+	// openAPI là tài liệu OpenAPI phục vụ tại /api/openapi.json, dựng trong Start
+	openAPI map[string]interface{}
 }
 
-func NewServer(bc *blockchain.Blockchain, cfg *config.Config) *Server {
+func NewServer(bc *blockchain.Blockchain, cfg *config.Config, faucet *wallet.Wallet, bus *events.Bus, network *p2p.Node) *Server {
 	return &Server{
 		blockchain: bc,
-		txPool:     pool.NewTransactionPool(),
 		config:     cfg,
+		faucet:     faucet,
+		events:     bus,
+		network:    network,
 	}
 }
 
@@ -42,45 +67,159 @@ func AllowedHeaders() gin.HandlerFunc {
 	}
 }
 
+// requireServerSideSigning chặn các endpoint nhận private key khi node tắt chế độ ký phía server
+func (s *Server) requireServerSideSigning() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.config.AllowServerSideSigning {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "Server-side signing is disabled on this node, submit a signed request instead",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// requireAdmin chỉ cho phép gọi endpoint quản trị khi có X-Admin-Token đúng,
+// hoặc từ localhost nếu node không cấu hình AdminToken
+func (s *Server) requireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.config.AdminToken != "" {
+			token := c.GetHeader("X-Admin-Token")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(s.config.AdminToken)) != 1 {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid admin token"})
+				return
+			}
+		} else if ip := net.ParseIP(c.ClientIP()); ip == nil || !ip.IsLoopback() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin API is only available from localhost"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// grantFaucetCoins gửi InitialWalletBalance từ ví faucet đến address. Coin được
+// cộng vào số dư khi giao dịch được đưa vào block.
+func (s *Server) grantFaucetCoins(address string) error {
+	if s.faucet == nil {
+		return fmt.Errorf("this node does not hold the faucet key")
+	}
+
+	s.faucetMutex.Lock()
+	defer s.faucetMutex.Unlock()
+
+	if s.blockchain.HasPendingTransfer(s.faucet.Address, address) {
+		return nil
+	}
+
+	nonce := s.blockchain.GetNextNonce(s.faucet.Address)
+	tx := pool.NewTransaction(s.faucet.Address, address, s.config.InitialWalletBalance, 0, nonce)
+	if err := tx.SignTransaction(s.faucet.PrivateKey); err != nil {
+		return err
+	}
+
+	return s.blockchain.AddTransaction(tx)
+}
+
+// submitSignedTransaction nhận giao dịch đã ký ở phía client, yêu cầu đúng loại txType
+func (s *Server) submitSignedTransaction(c *gin.Context, txType pool.TransactionType) (*pool.Transaction, bool) {
+	var tx pool.Transaction
+
+	if err := c.ShouldBindJSON(&tx); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction body"})
+		return nil, false
+	}
+
+	if tx.Type != txType {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Expected a %s transaction", txType)})
+		return nil, false
+	}
+
+	if err := s.blockchain.AddTransaction(&tx); err != nil {
+		log.Printf("Signed %s transaction rejected: %v", txType, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	return &tx, true
+}
+
 func (s *Server) Start() error {
 	router := gin.Default()
 
 	router.Use(AllowedHeaders())
+	router.POST("/rpc", s.handleRPC)
 	api := router.Group("/api")
 	{
+		api.GET("/openapi.json", s.getOpenAPI)
+
 		// wallet endpoints
 		walletApi := api.Group("/wallet")
 		{
 			walletApi.POST("/create", s.createWallet)
 			walletApi.POST("/import", s.importWallet)
 			walletApi.GET("/balance/:address", s.getBalance)
+			walletApi.GET("/nonce/:address", s.getNonce)
 		}
 
 		blockChainApi := api.Group("/blockchain")
 		{
-			blockChainApi.POST("/mine", s.mineBlock)
+			blockChainApi.POST("/mine", s.requireServerSideSigning(), s.mineBlock)
+			blockChainApi.POST("/mine/template", s.getBlockTemplate)
+			blockChainApi.POST("/mine/submit", s.submitBlock)
 			blockChainApi.GET("/info", s.getBlockchainInfo)
-			// blockChainApi.GET("/blocks", s.getAllBlocks)
-			// blockChainApi.GET("/block/:index", s.getBlock)
+			blockChainApi.GET("/proof/:hash", s.getMerkleProof)
+			blockChainApi.GET("/blocks", s.getAllBlocks)
+			blockChainApi.GET("/block/:index", s.getBlock)
+			blockChainApi.GET("/block/hash/:hash", s.getBlockByHash)
+		}
+
+		eventsApi := api.Group("/events")
+		{
+			eventsApi.GET("/ws", s.streamEventsWebSocket)
+			eventsApi.GET("/sse", s.streamEventsSSE)
 		}
 
 		stakingApi := api.Group("/staking")
 		{
-			stakingApi.POST("/stake", s.stakeCoins)
-			stakingApi.POST("/unstake", s.unstakeCoins)
+			stakingApi.POST("/stake", s.requireServerSideSigning(), s.stakeCoins)
+			stakingApi.POST("/unstake", s.requireServerSideSigning(), s.unstakeCoins)
+			stakingApi.POST("/stake/signed", s.stakeCoinsSigned)
+			stakingApi.POST("/unstake/signed", s.unstakeCoinsSigned)
 			stakingApi.GET("/validators", s.getValidators)
 			stakingApi.GET("/validator/:address", s.getValidatorInfo)
 			stakingApi.GET("/info", s.getStakingInfo)
 		}
 
+		networkApi := api.Group("/network")
+		{
+			networkApi.GET("/peers", s.getPeers)
+		}
+
+		adminApi := api.Group("/admin", s.requireAdmin())
+		{
+			adminApi.GET("/validate-chain", s.validateChain)
+		}
+
 		transactionApi := api.Group("/transaction")
 		{
-			transactionApi.POST("/send", s.sendTransaction)
+			transactionApi.POST("/send", s.requireServerSideSigning(), s.sendTransaction)
+			transactionApi.POST("/broadcast", s.broadcastTransaction)
+			transactionApi.POST("/replace", s.replaceTransaction)
+			transactionApi.POST("/cancel", s.requireServerSideSigning(), s.cancelTransaction)
 			transactionApi.GET("/history/:address", s.getTransactionHistory)
+			transactionApi.GET("/history/:address/export", s.exportTransactionHistory)
+			transactionApi.GET("/:hash", s.getTransactionStatus)
 		}
 
 	}
 
+	operations := apiOperations()
+	if err := checkAPIRoutes(router.Routes(), operations); err != nil {
+		return err
+	}
+	s.openAPI = buildOpenAPI(operations)
+
 	router.Static("/static", "./web/static")
 	router.StaticFile("/", "./web/static/index.html")
 	router.StaticFile("/favicon.ico", "./web/static/favicon.ico")
@@ -95,8 +234,11 @@ func (s *Server) createWallet(c *gin.Context) {
 	newWallet := wallet.NewWallet()
 
 	log.Printf("New wallet created: %s", newWallet.Address)
-	s.blockchain.AddBalance(newWallet.Address, s.config.InitialWalletBalance)
-	log.Printf("Initial balance of %.2f added to wallet %s", s.config.InitialWalletBalance, newWallet.Address)
+	if err := s.grantFaucetCoins(newWallet.Address); err != nil {
+		log.Printf("Failed to add initial balance: %v", err)
+	} else {
+		log.Printf("Faucet transfer of %s MYC to wallet %s is pending", s.config.InitialWalletBalance, newWallet.Address)
+	}
 
 	response := models.CreateWalletResponse{
 		Address:    newWallet.Address,
@@ -107,80 +249,168 @@ func (s *Server) createWallet(c *gin.Context) {
 }
 
 func (s *Server) getBalance(c *gin.Context) {
-	address := c.Param("address")
-	balance := s.blockchain.GetBalance(address)
-
-	response := models.BalanceResponse{
-		Address: address,
-		Balance: balance,
-	}
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, s.balance(c.Param("address")))
+}
 
+func (s *Server) getNonce(c *gin.Context) {
+	c.JSON(http.StatusOK, s.nonce(c.Param("address")))
 }
 
 func (s *Server) importWallet(c *gin.Context) {
-	var request struct {
-		PrivateKey string `json:"private_key,omitempty"`
-		Passphrase string `json:"passphrase,omitempty"`
-	}
+	var request models.ImportWalletRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
 
-	var importedWallet *wallet.Wallet
-	var err error
+	var response models.CreateWalletResponse
+
+	if request.PublicKey != "" {
+		// Khóa riêng ở lại phía client, node chỉ cần public key để tính địa chỉ
+		publicKey, err := wallet.ParsePublicKey(request.PublicKey)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to import wallet: " + err.Error()})
+			return
+		}
+		response.Address = wallet.AddressFromPublicKey(publicKey)
+		response.PublicKey = hex.EncodeToString(publicKey)
+	} else if request.PrivateKey != "" || request.Passphrase != "" {
+		if !s.config.AllowServerSideSigning {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Server-side signing is disabled on this node, import the wallet by public_key instead",
+			})
+			return
+		}
+
+		var importedWallet *wallet.Wallet
+		var err error
+		if request.PrivateKey != "" {
+			importedWallet, err = wallet.LoadWalletFromPrivateKey(request.PrivateKey)
+		} else {
+			importedWallet = wallet.NewWalletFromPassphrase(request.Passphrase)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to import wallet: " + err.Error()})
+			return
+		}
 
-	if request.PrivateKey != "" {
-		importedWallet, err = wallet.LoadWalletFromPrivateKey(request.PrivateKey)
-	} else if request.Passphrase != "" {
-		importedWallet = wallet.NewWalletFromPassphrase(request.Passphrase)
+		response.Address = importedWallet.Address
+		response.PublicKey = importedWallet.GetPublicKeyHex()
+		response.PrivateKey = importedWallet.GetPrivateKeyHex()
 	} else {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Either private_key or passphrase is required"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "One of public_key, private_key or passphrase is required"})
 		return
 	}
 
+	// Check if wallet already has balance, if not send free coins from the faucet
+	currentBalance := s.blockchain.GetBalance(response.Address)
+	if currentBalance.IsZero() {
+		if err := s.grantFaucetCoins(response.Address); err != nil {
+			log.Printf("Failed to add initial balance: %v", err)
+		} else {
+			log.Printf("Faucet transfer of %s MYC to imported wallet is pending", s.config.InitialWalletBalance)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Blockchain handlers
+func (s *Server) getBlockchainInfo(c *gin.Context) {
+	info := models.BlockchainInfoResponse{
+		ChainLength:         s.blockchain.Height(),
+		PendingTransactions: s.blockchain.TxPool.Len(),
+		MiningReward:        s.blockchain.MiningReward,
+		IsValid:             s.blockchain.IsChainValid(),
+		LatestBlock:         s.blockchain.DescribeBlock(s.blockchain.GetLatestBlock()),
+		Sync:                s.network.SyncStatus(),
+		ForkChoice:          s.blockchain.GetForkChoiceInfo(),
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// Giới hạn số block trả về trong một trang của getAllBlocks
+const (
+	defaultBlocksLimit = 20
+	maxBlocksLimit     = 100
+)
+
+// getAllBlocks trả về một trang block: from là index bắt đầu (mặc định là đầu chain
+// theo thứ tự đã chọn), limit tối đa maxBlocksLimit, order là asc hoặc desc (mặc định)
+func (s *Server) getAllBlocks(c *gin.Context) {
+	query, err := parseBlockListQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to import wallet: " + err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Check if wallet already has balance, if not give free coins directly
-	currentBalance := s.blockchain.GetBalance(importedWallet.Address)
-	if currentBalance == 0 {
-		s.blockchain.AddBalance(importedWallet.Address, s.config.InitialWalletBalance)
-		log.Printf("Added %.2f MYC directly to imported wallet balance", s.config.InitialWalletBalance)
+	from := int64(-1)
+	if query.From != nil {
+		from = *query.From
+	}
+	c.JSON(http.StatusOK, s.blockchain.GetBlocks(from, query.Limit, query.Order == models.OrderDesc))
+}
+
+func parseBlockListQuery(c *gin.Context) (*models.BlockListQuery, error) {
+	query := &models.BlockListQuery{
+		Limit: defaultBlocksLimit,
+		Order: c.DefaultQuery("order", models.OrderDesc),
 	}
 
-	response := models.CreateWalletResponse{
-		Address:    importedWallet.Address,
-		PrivateKey: importedWallet.GetPrivateKeyHex(),
-		PublicKey:  importedWallet.GetPublicKeyHex(),
+	if value := c.Query("from"); value != "" {
+		from, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || from < 0 {
+			return nil, fmt.Errorf("from must be a non-negative block index")
+		}
+		query.From = &from
 	}
 
-	c.JSON(http.StatusOK, response)
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxBlocksLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxBlocksLimit)
+		}
+		query.Limit = limit
+	}
+
+	if query.Order != models.OrderAsc && query.Order != models.OrderDesc {
+		return nil, fmt.Errorf("order must be asc or desc")
+	}
+	return query, nil
 }
 
-// Blockchain handlers
-func (s *Server) getBlockchainInfo(c *gin.Context) {
-	info := gin.H{
-		"chain_length":         len(s.blockchain.Chain),
-		"pending_transactions": len(s.blockchain.PendingTransactions),
-		"mining_reward":        s.blockchain.MiningReward,
-		"is_valid":             s.blockchain.IsChainValid(),
-		"latest_block":         s.blockchain.GetLatestBlock(),
+func (s *Server) getBlock(c *gin.Context) {
+	index, err := strconv.ParseInt(c.Param("index"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid block index"})
+		return
 	}
 
-	c.JSON(http.StatusOK, info)
+	block, err := s.blockchain.GetBlockByIndex(index)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, block)
+}
+
+func (s *Server) getBlockByHash(c *gin.Context) {
+	block, err := s.blockchain.GetBlockByHash(c.Param("hash"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, block)
 }
 
 func (s *Server) mineBlock(c *gin.Context) {
 	log.Println("=== CREATE BLOCK REQUEST ===")
 
-	var request struct {
-		MinerAddress string `json:"miner_address"` // ❌ User chooses who mines!
-	}
+	var request models.MineBlockRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
 		log.Printf("Failed to decode request body: %v", err)
@@ -188,62 +418,60 @@ func (s *Server) mineBlock(c *gin.Context) {
 		return
 	}
 
-	// No user input - system selects validator
-	selectedValidator, err := s.blockchain.StakingPool.SelectValidator()
-	if selectedValidator == "" {
-		log.Printf("Failed to select validator: %v", err)
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "Không có validator nào khả dụng để tạo block. Vui lòng stake coin để trở thành validator.",
-		})
+	if !s.checkSelectedValidator(c, request.MinerAddress) {
 		return
 	}
-	if err != nil {
-		log.Printf("Failed to select validator: %v", err)
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "Không có validator nào khả dụng để tạo block. Vui lòng stake coin để trở thành validator.",
-		})
+
+	validatorWallet, err := wallet.LoadWalletFromPrivateKey(request.PrivateKey)
+	if request.PrivateKey == "" || err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid private key"})
 		return
 	}
-	if selectedValidator != request.MinerAddress {
-		log.Printf("Mining denied for address: %s (selected: %s)", request.MinerAddress, selectedValidator)
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "Bạn không phải validator được chọn để tạo block!!",
-		})
+	if validatorWallet.Address != request.MinerAddress {
+		log.Printf("Address mismatch: wallet=%s, request=%s", validatorWallet.Address, request.MinerAddress)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Private key does not match miner address"})
 		return
 	}
-	if len(s.blockchain.PendingTransactions) == 0 {
+
+	if s.blockchain.TxPool.Len() == 0 {
 		log.Println("No pending transactions - will create block with reward transaction only")
 	}
 
 	log.Println("Creating block...")
 
+	type mineResult struct {
+		block *blockchain.Block
+		err   error
+	}
+
 	// Add timeout protection
-	done := make(chan *blockchain.Block, 1)
+	done := make(chan mineResult, 1)
 	go func() {
-		block := s.blockchain.MinePendingTransactions(selectedValidator)
-		done <- block
+		block, err := s.blockchain.MinePendingTransactions(validatorWallet)
+		done <- mineResult{block: block, err: err}
 	}()
 
 	select {
-	case block := <-done:
-		if block == nil {
-			log.Printf("Mining denied for address: %s", selectedValidator)
+	case result := <-done:
+		if result.err != nil {
+			log.Printf("Mining denied for address: %s: %v", validatorWallet.Address, result.err)
 			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Bạn không phải validator",
+				"error": result.err.Error(),
 			})
 			return
 		}
+		block := result.block
 
 		log.Printf("Block created successfully!")
 		log.Printf("- Hash: %s", block.Hash)
 		log.Printf("- Index: %d", block.Index)
 		log.Printf("- Transactions: %d", len(block.Transactions))
-		log.Printf("- Reward recipient: %s", selectedValidator)
+		log.Printf("- Reward recipient: %s", block.Validator)
 
-		c.JSON(http.StatusOK, gin.H{
-			"status":     "success",
-			"block_hash": block.Hash,
-			"block":      block,
+		c.JSON(http.StatusOK, models.MineBlockResponse{
+			Status:    "success",
+			BlockHash: block.Hash,
+			Block:     s.blockchain.DescribeBlock(block),
 		})
 		return
 
@@ -254,23 +482,108 @@ func (s *Server) mineBlock(c *gin.Context) {
 	}
 }
 
-// Staking handlers
-func (s *Server) getValidators(c *gin.Context) {
-	validators := s.blockchain.GetValidators()
+// checkSelectedValidator chọn ngẫu nhiên validator theo stake và chỉ cho phép
+// address tạo block khi được chọn. Trả về false nếu đã ghi response lỗi.
+func (s *Server) checkSelectedValidator(c *gin.Context, address string) bool {
+	// No user input - system selects validator
+	selectedValidator, err := s.blockchain.SelectValidator()
+	if selectedValidator == "" || err != nil {
+		log.Printf("Failed to select validator: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Không có validator nào khả dụng để tạo block. Vui lòng stake coin để trở thành validator.",
+		})
+		return false
+	}
+	if selectedValidator != address {
+		log.Printf("Mining denied for address: %s (selected: %s)", address, selectedValidator)
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Bạn không phải validator được chọn để tạo block!!",
+		})
+		return false
+	}
+	return true
+}
+
+// getBlockTemplate trả về block chưa ký cho validator được chọn; validator ký
+// block_hash ở phía client rồi gửi block qua /blockchain/mine/submit
+func (s *Server) getBlockTemplate(c *gin.Context) {
+	var request models.BlockTemplateRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if !s.checkSelectedValidator(c, request.MinerAddress) {
+		return
+	}
+
+	block, err := s.blockchain.PrepareBlock(request.MinerAddress)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MineBlockResponse{
+		Status:    "success",
+		BlockHash: block.Hash,
+		Block:     s.blockchain.DescribeBlock(block),
+	})
+}
+
+// submitBlock nhận block đã được validator ký ở phía client
+func (s *Server) submitBlock(c *gin.Context) {
+	var block blockchain.Block
+
+	if err := c.ShouldBindJSON(&block); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid block body"})
+		return
+	}
+
+	if err := s.blockchain.AddBlock(&block); err != nil {
+		log.Printf("Submitted block rejected: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("Submitted block #%d accepted from validator %s", block.Index, block.Validator)
 
-	c.JSON(http.StatusOK, gin.H{
-		"validators": validators,
-		"count":      len(validators),
+	c.JSON(http.StatusOK, models.MineBlockResponse{
+		Status:    "success",
+		BlockHash: block.Hash,
+		Block:     s.blockchain.DescribeBlock(&block),
 	})
 }
 
+// getMerkleProof trả về Merkle proof chứng minh giao dịch đã nằm trong một block
+func (s *Server) getMerkleProof(c *gin.Context) {
+	proof, err := s.blockchain.GetMerkleProof(c.Param("hash"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MerkleProofResponse{Proof: proof})
+}
+
+// Admin handlers
+func (s *Server) validateChain(c *gin.Context) {
+	result := s.blockchain.ValidateChain()
+	if !result.Valid {
+		log.Printf("Chain validation failed at block %d: %s", result.InvalidBlock, result.Reason)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Staking handlers
+func (s *Server) getValidators(c *gin.Context) {
+	c.JSON(http.StatusOK, s.validators())
+}
+
 func (s *Server) stakeCoins(c *gin.Context) {
 	log.Println("=== STAKE COINS REQUEST ===")
-	var request struct {
-		Address    string  `json:"address"`
-		Amount     float64 `json:"amount"`
-		PrivateKey string  `json:"private_key"`
-	}
+	var request models.StakeRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
 		log.Printf("Failed to decode request body: %v", err)
@@ -278,7 +591,7 @@ func (s *Server) stakeCoins(c *gin.Context) {
 		return
 	}
 
-	log.Printf("Stake request - Address: %s, Amount: %.2f", request.Address, request.Amount)
+	log.Printf("Stake request - Address: %s, Amount: %s", request.Address, request.Amount)
 
 	// Verify private key matches address
 	log.Printf("Loading wallet from private key...")
@@ -299,19 +612,42 @@ func (s *Server) stakeCoins(c *gin.Context) {
 
 	// Stake coins
 	log.Printf("Starting stake operation...")
-	err = s.blockchain.StakeCoins(request.Address, request.Amount)
-	if err != nil {
+	nonce := s.blockchain.GetNextNonce(request.Address)
+	tx := pool.NewStakeTransaction(request.Address, request.Amount, 0, nonce)
+	if err := tx.SignTransaction(userWallet.PrivateKey); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign transaction"})
+		return
+	}
+
+	if err := s.blockchain.AddTransaction(tx); err != nil {
 		log.Printf("Stake operation failed: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	log.Printf("Stake operation completed successfully")
+	log.Printf("Stake transaction added to pending pool")
+
+	c.JSON(http.StatusOK, models.StakeResponse{
+		Status:          "success",
+		Message:         fmt.Sprintf("Stake of %s MYC will take effect in the next block", request.Amount),
+		Address:         request.Address,
+		Amount:          request.Amount,
+		TransactionHash: tx.Hash,
+	})
+}
+
+// stakeCoinsSigned nhận giao dịch stake đã được ký ở phía client
+func (s *Server) stakeCoinsSigned(c *gin.Context) {
+	tx, ok := s.submitSignedTransaction(c, pool.TxStake)
+	if !ok {
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": fmt.Sprintf("Successfully staked %.2f MYC", request.Amount),
-		"address": request.Address,
-		"amount":  request.Amount,
+	c.JSON(http.StatusOK, models.StakeResponse{
+		Status:          "success",
+		Message:         fmt.Sprintf("Stake of %s MYC will take effect in the next block", tx.Amount),
+		Address:         tx.From,
+		Amount:          tx.Amount,
+		TransactionHash: tx.Hash,
 	})
 }
 
@@ -321,6 +657,10 @@ func (s *Server) getStakingInfo(c *gin.Context) {
 	c.JSON(http.StatusOK, info)
 }
 
+func (s *Server) getPeers(c *gin.Context) {
+	c.JSON(http.StatusOK, s.network.Peers())
+}
+
 func (s *Server) getValidatorInfo(c *gin.Context) {
 	address := c.Param("address")
 
@@ -334,10 +674,7 @@ func (s *Server) getValidatorInfo(c *gin.Context) {
 }
 
 func (s *Server) unstakeCoins(c *gin.Context) {
-	var request struct {
-		Address    string `json:"address"`
-		PrivateKey string `json:"private_key"`
-	}
+	var request models.UnstakeRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
@@ -364,40 +701,232 @@ func (s *Server) unstakeCoins(c *gin.Context) {
 	}
 
 	// Unstake coins
-	err = s.blockchain.UnstakeCoins(request.Address)
-	if err != nil {
+	nonce := s.blockchain.GetNextNonce(request.Address)
+	tx := pool.NewUnstakeTransaction(request.Address, 0, nonce)
+	if err := tx.SignTransaction(userWallet.PrivateKey); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign transaction"})
+		return
+	}
+
+	if err := s.blockchain.AddTransaction(tx); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": fmt.Sprintf("Successfully unstaked %.2f MYC", validator.StakedAmount),
-		"address": request.Address,
-		"amount":  validator.StakedAmount,
+	c.JSON(http.StatusOK, models.StakeResponse{
+		Status:          "success",
+		Message:         fmt.Sprintf("Unstake of %s MYC will take effect in the next block", validator.StakedAmount),
+		Address:         request.Address,
+		Amount:          validator.StakedAmount,
+		TransactionHash: tx.Hash,
+	})
+}
+
+// unstakeCoinsSigned nhận giao dịch unstake đã được ký ở phía client
+func (s *Server) unstakeCoinsSigned(c *gin.Context) {
+	tx, ok := s.submitSignedTransaction(c, pool.TxUnstake)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, models.StakeResponse{
+		Status:          "success",
+		Message:         "Unstake will take effect in the next block",
+		Address:         tx.From,
+		TransactionHash: tx.Hash,
 	})
 }
 
 // Transaction handlers
+// Giới hạn số giao dịch trả về trong một trang của getTransactionHistory
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+)
+
+// getTransactionHistory trả về một trang lịch sử giao dịch của địa chỉ.
+// Query: direction (in|out), min_amount, max_amount (MYC), from_time, to_time (Unix),
+// from_block, to_block, order (asc|desc), limit, cursor, include_pending (true|false).
 func (s *Server) getTransactionHistory(c *gin.Context) {
 	address := c.Param("address")
 
-	transactions := s.blockchain.GetTransactionHistoryWithBlocks(address)
+	query, err := parseHistoryQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	response := models.TransactionHistoryResponse{
-		Address:      address,
-		Transactions: transactions,
+	response, err := s.blockchain.QueryTransactionHistory(address, query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+func parseHistoryQuery(c *gin.Context) (*models.TransactionHistoryQuery, error) {
+	query := &models.TransactionHistoryQuery{
+		Direction: c.Query("direction"),
+		Order:     c.DefaultQuery("order", models.OrderAsc),
+		Limit:     defaultHistoryLimit,
+		Cursor:    c.Query("cursor"),
+	}
+
+	if query.Direction != "" && query.Direction != models.DirectionIn && query.Direction != models.DirectionOut {
+		return nil, fmt.Errorf("direction must be in or out")
+	}
+	if query.Order != models.OrderAsc && query.Order != models.OrderDesc {
+		return nil, fmt.Errorf("order must be asc or desc")
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxHistoryLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxHistoryLimit)
+		}
+		query.Limit = limit
+	}
+
+	for name, target := range map[string]**coin.Amount{"min_amount": &query.MinAmount, "max_amount": &query.MaxAmount} {
+		if value := c.Query(name); value != "" {
+			amount, err := coin.ParseAmount(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %v", name, err)
+			}
+			*target = &amount
+		}
+	}
+
+	for name, target := range map[string]*int64{"from_time": &query.FromTime, "to_time": &query.ToTime} {
+		if value := c.Query(name); value != "" {
+			timestamp, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || timestamp <= 0 {
+				return nil, fmt.Errorf("%s must be a positive Unix timestamp", name)
+			}
+			*target = timestamp
+		}
+	}
+
+	for name, target := range map[string]**int64{"from_block": &query.FromBlock, "to_block": &query.ToBlock} {
+		if value := c.Query(name); value != "" {
+			index, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("%s must be a non-negative block index", name)
+			}
+			*target = &index
+		}
+	}
+
+	if value := c.Query("include_pending"); value != "" {
+		includePending, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("include_pending must be true or false")
+		}
+		query.IncludePending = includePending
+	}
+
+	return query, nil
+}
+
+// exportTransactionHistory ghi toàn bộ lịch sử đã xác nhận của địa chỉ (kèm số dư sau
+// mỗi giao dịch) dưới dạng CSV hoặc JSON, ghi dần từng dòng thay vì dựng cả response
+func (s *Server) exportTransactionHistory(c *gin.Context) {
+	address := c.Param("address")
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or json"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=history-%s.%s", address, format))
+
+	var writeRecord func(*models.HistoryRecord) error
+	var finish func() error
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		writer := csv.NewWriter(c.Writer)
+		if err := writer.Write([]string{"hash", "block_index", "block_hash", "time", "type",
+			"counterparty", "received", "sent", "fee", "balance"}); err != nil {
+			return
+		}
+		writeRecord = func(record *models.HistoryRecord) error {
+			writer.Write([]string{
+				record.Hash,
+				strconv.FormatInt(record.BlockIndex, 10),
+				record.BlockHash,
+				time.Unix(record.Timestamp, 0).UTC().Format(time.RFC3339),
+				string(record.Type),
+				record.Counterparty,
+				record.Received.String(),
+				record.Sent.String(),
+				record.Fee.String(),
+				record.Balance.String(),
+			})
+			writer.Flush()
+			return writer.Error()
+		}
+		finish = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	} else {
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		encoder := json.NewEncoder(c.Writer)
+		if _, err := c.Writer.WriteString("["); err != nil {
+			return
+		}
+		first := true
+		writeRecord = func(record *models.HistoryRecord) error {
+			if !first {
+				if _, err := c.Writer.WriteString(","); err != nil {
+					return err
+				}
+			}
+			first = false
+			return encoder.Encode(record)
+		}
+		finish = func() error {
+			_, err := c.Writer.WriteString("]\n")
+			return err
+		}
+	}
+
+	// Header đã được gửi nên lỗi giữa chừng chỉ có thể ghi log và cắt response
+	err := s.blockchain.ExportTransactionHistory(address, func(record *models.HistoryRecord) error {
+		if err := writeRecord(record); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err == nil {
+		err = finish()
+	}
+	if err != nil {
+		log.Printf("Export of %s history aborted: %v", address, err)
+	}
+}
+
+// getTransactionStatus trả về trạng thái của giao dịch theo hash: đang chờ,
+// đã xác nhận (kèm receipt) hoặc bị loại/từ chối (kèm lý do)
+func (s *Server) getTransactionStatus(c *gin.Context) {
+	hash := c.Param("hash")
+
+	status, found := s.blockchain.GetTransactionStatus(hash)
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("transaction %s not found", hash)})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
 func (s *Server) sendTransaction(c *gin.Context) {
 	/* Steps to handle a transaction:
 	1. Parse and validate request body
 	2. Load wallet from private key and verify 'from' address
 	3. Check balance of 'from' address
-	4. Create transaction and add to pending pool
+	4. Create transaction, sign it and add to pending pool
 	5. Return success response with transaction hash
 	*/
 
@@ -409,7 +938,7 @@ func (s *Server) sendTransaction(c *gin.Context) {
 		return
 	}
 
-	log.Printf("Transaction request: From=%s, To=%s, Amount=%.2f, Fee=%.2f",
+	log.Printf("Transaction request: From=%s, To=%s, Amount=%s, Fee=%s",
 		request.From, request.To, request.Amount, request.Fee)
 
 	userWallet, err := wallet.LoadWalletFromPrivateKey(request.PrivateKey)
@@ -428,13 +957,24 @@ func (s *Server) sendTransaction(c *gin.Context) {
 	}
 
 	// Check current balance before transaction
-	currentBalance := s.blockchain.GetBalance(request.From)
-	log.Printf("Current balance for %s: %.2f MYC", request.From, currentBalance)
-	log.Printf("Required amount: %.2f MYC (%.2f + %.2f fee)", request.Amount+request.Fee, request.Amount, request.Fee)
-
-	tx := pool.NewTransaction(request.From, request.To, request.Amount, request.Fee)
+	currentBalance := s.blockchain.GetAvailableBalance(request.From)
+	log.Printf("Available balance for %s: %s MYC", request.From, currentBalance)
+	log.Printf("Required amount: %s MYC + %s MYC fee", request.Amount, request.Fee)
+
+	nonce := s.blockchain.GetNextNonce(request.From)
+	tx := pool.NewTransaction(request.From, request.To, request.Amount, request.Fee, nonce)
+	if request.ValidUntilHeight != 0 {
+		tx.ValidUntilHeight = request.ValidUntilHeight
+		tx.Hash = tx.CalculateHash()
+	}
 	log.Printf("Transaction created with hash: %s", tx.Hash)
 
+	if err := tx.SignTransaction(userWallet.PrivateKey); err != nil {
+		log.Printf("Error signing transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign transaction"})
+		return
+	}
+
 	if err := s.blockchain.AddTransaction(tx); err != nil {
 		log.Printf("Error adding transaction: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -442,11 +982,103 @@ func (s *Server) sendTransaction(c *gin.Context) {
 	}
 
 	log.Printf("Transaction successfully added to pending pool")
-	log.Printf("Pending transactions count: %d", len(s.blockchain.PendingTransactions))
+	log.Printf("Pending transactions count: %d", s.blockchain.TxPool.Len())
+
+	c.JSON(http.StatusOK, models.TransactionSubmitResponse{
+		Status:          "success",
+		TransactionHash: tx.Hash,
+		Message:         "Transaction added to pending pool",
+	})
+}
+
+// broadcastTransaction nhận một giao dịch đã được ký đầy đủ ở phía client
+func (s *Server) broadcastTransaction(c *gin.Context) {
+	var tx pool.Transaction
+
+	if err := c.ShouldBindJSON(&tx); err != nil {
+		log.Printf("Error decoding raw transaction: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction body"})
+		return
+	}
+
+	if err := s.broadcast(&tx); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TransactionSubmitResponse{
+		Status:          "success",
+		TransactionHash: tx.Hash,
+		Message:         "Transaction added to pending pool",
+	})
+}
+
+// replaceTransaction nhận giao dịch đã ký có cùng nonce với một giao dịch đang chờ và phí
+// cao hơn (replace-by-fee). Giao dịch chuyển 0 coin cho chính mình dùng để hủy.
+func (s *Server) replaceTransaction(c *gin.Context) {
+	var tx pool.Transaction
+
+	if err := c.ShouldBindJSON(&tx); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction body"})
+		return
+	}
+
+	replaced, err := s.blockchain.ReplaceTransaction(&tx)
+	if err != nil {
+		log.Printf("Replacement transaction rejected: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("Transaction %s replaced by %s (fee %s -> %s MYC)", replaced.Hash, tx.Hash, replaced.Fee, tx.Fee)
+
+	c.JSON(http.StatusOK, models.TransactionSubmitResponse{
+		Status:          "success",
+		TransactionHash: tx.Hash,
+		ReplacedHash:    replaced.Hash,
+		Message:         "Pending transaction replaced",
+	})
+}
+
+// cancelTransaction hủy giao dịch đang chờ bằng giao dịch chuyển 0 coin cho chính mình
+// với cùng nonce và phí cao hơn, ký phía server bằng private key của người gửi
+func (s *Server) cancelTransaction(c *gin.Context) {
+	var request models.CancelTransactionRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	userWallet, err := wallet.LoadWalletFromPrivateKey(request.PrivateKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid private key"})
+		return
+	}
+	if userWallet.Address != request.From {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Private key does not match from address"})
+		return
+	}
+
+	tx := pool.NewCancelTransaction(request.From, request.Fee, request.Nonce)
+	if err := tx.SignTransaction(userWallet.PrivateKey); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign transaction"})
+		return
+	}
+
+	replaced, err := s.blockchain.ReplaceTransaction(tx)
+	if err != nil {
+		log.Printf("Cancel transaction rejected: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("Transaction %s cancelled by %s", replaced.Hash, tx.Hash)
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":           "success",
-		"transaction_hash": tx.Hash,
-		"message":          "Transaction added to pending pool",
+	c.JSON(http.StatusOK, models.TransactionSubmitResponse{
+		Status:          "success",
+		TransactionHash: tx.Hash,
+		ReplacedHash:    replaced.Hash,
+		Message:         "Pending transaction cancelled",
 	})
 }
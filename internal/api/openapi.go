@@ -0,0 +1,422 @@
+package api
+
+import (
+	"MyCoinApp/internal/blockchain"
+	"MyCoinApp/internal/coin"
+	"MyCoinApp/internal/consensus"
+	"MyCoinApp/internal/events"
+	"MyCoinApp/internal/models"
+	"MyCoinApp/internal/pool"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiParam là một query parameter của endpoint; typ là kiểu OpenAPI (string, integer...)
+type apiParam struct {
+	name        string
+	typ         string
+	description string
+}
+
+// apiOperation mô tả một route trong tài liệu OpenAPI. path dùng cú pháp của gin
+// (/block/:index); request và response là giá trị mẫu của kiểu body, nil nếu không có.
+type apiOperation struct {
+	method  string
+	path    string
+	tag     string
+	summary string
+	query   []apiParam
+	request interface{}
+	// batch cho phép body và response là mảng các request/response (JSON-RPC)
+	batch    bool
+	response interface{}
+	// contentTypes của response thành công, mặc định application/json
+	contentTypes []string
+	// status là mã thành công nếu khác 200
+	status int
+	// noContent là có thể trả về 204 không có body
+	noContent bool
+	errors    []int
+	// admin là cần header X-Admin-Token (xem requireAdmin)
+	admin bool
+}
+
+var historyQueryParams = []apiParam{
+	{"direction", "string", "in hoặc out, bỏ trống là cả hai chiều"},
+	{"min_amount", "number", "Số MYC tối thiểu"},
+	{"max_amount", "number", "Số MYC tối đa"},
+	{"from_time", "integer", "Unix timestamp bắt đầu (tính cả)"},
+	{"to_time", "integer", "Unix timestamp kết thúc (tính cả)"},
+	{"from_block", "integer", "Index block bắt đầu (tính cả)"},
+	{"to_block", "integer", "Index block kết thúc (tính cả)"},
+	{"order", "string", "asc (mặc định) hoặc desc"},
+	{"limit", "integer", fmt.Sprintf("Số giao dịch mỗi trang, mặc định %d, tối đa %d", defaultHistoryLimit, maxHistoryLimit)},
+	{"cursor", "string", "next_cursor của trang trước"},
+	{"include_pending", "boolean", "Thêm các giao dịch đang chờ"},
+}
+
+var eventQueryParams = []apiParam{
+	{"topics", "string", "Danh sách topic phân tách bằng dấu phẩy: block, transaction, validator, balance"},
+	{"addresses", "string", "Danh sách địa chỉ phân tách bằng dấu phẩy"},
+}
+
+// apiOperations là toàn bộ route của REST API và JSON-RPC; Start kiểm tra danh sách
+// này khớp đúng với các route đã đăng ký
+func apiOperations() []apiOperation {
+	return []apiOperation{
+		{method: "POST", path: "/rpc", tag: "rpc", summary: "Gọi JSON-RPC 2.0 (request đơn lẻ hoặc batch)",
+			request: models.RPCRequest{}, batch: true, response: models.RPCResponse{}, noContent: true},
+		{method: "GET", path: "/api/openapi.json", tag: "meta", summary: "Tài liệu OpenAPI của node",
+			response: map[string]interface{}{}},
+
+		{method: "POST", path: "/api/wallet/create", tag: "wallet", summary: "Tạo ví mới và nhận coin từ faucet",
+			response: models.CreateWalletResponse{}},
+		{method: "POST", path: "/api/wallet/import", tag: "wallet", summary: "Nhập ví bằng public key (private key hoặc passphrase khi bật ký phía server)",
+			request: models.ImportWalletRequest{}, response: models.CreateWalletResponse{}, errors: []int{400, 403}},
+		{method: "GET", path: "/api/wallet/balance/:address", tag: "wallet", summary: "Số dư đã xác nhận và số dư khả dụng",
+			response: models.BalanceResponse{}},
+		{method: "GET", path: "/api/wallet/nonce/:address", tag: "wallet", summary: "Nonce tiếp theo và nonce đã xác nhận",
+			response: models.NonceResponse{}},
+
+		{method: "POST", path: "/api/blockchain/mine", tag: "blockchain", summary: "Tạo block, ký phía server bằng private key của validator",
+			request: models.MineBlockRequest{}, response: models.MineBlockResponse{}, errors: []int{400, 403, 408, 503}},
+		{method: "POST", path: "/api/blockchain/mine/template", tag: "blockchain", summary: "Lấy block chưa ký cho validator được chọn",
+			request: models.BlockTemplateRequest{}, response: models.MineBlockResponse{}, errors: []int{400, 403, 503}},
+		{method: "POST", path: "/api/blockchain/mine/submit", tag: "blockchain", summary: "Gửi block đã được validator ký",
+			request: blockchain.Block{}, response: models.MineBlockResponse{}, errors: []int{400}},
+		{method: "GET", path: "/api/blockchain/info", tag: "blockchain", summary: "Thông tin tổng quan của chain",
+			response: models.BlockchainInfoResponse{}},
+		{method: "GET", path: "/api/blockchain/proof/:hash", tag: "blockchain", summary: "Merkle proof của giao dịch đã xác nhận",
+			response: models.MerkleProofResponse{}, errors: []int{404}},
+		{method: "GET", path: "/api/blockchain/blocks", tag: "blockchain", summary: "Danh sách block có phân trang",
+			query: []apiParam{
+				{"from", "integer", "Index block bắt đầu, mặc định là đầu chain theo thứ tự đã chọn"},
+				{"limit", "integer", fmt.Sprintf("Số block mỗi trang, mặc định %d, tối đa %d", defaultBlocksLimit, maxBlocksLimit)},
+				{"order", "string", "asc hoặc desc (mặc định)"},
+			},
+			response: models.BlockListResponse{}, errors: []int{400}},
+		{method: "GET", path: "/api/blockchain/block/:index", tag: "blockchain", summary: "Block theo index",
+			response: models.BlockResponse{}, errors: []int{400, 404}},
+		{method: "GET", path: "/api/blockchain/block/hash/:hash", tag: "blockchain", summary: "Block theo hash",
+			response: models.BlockResponse{}, errors: []int{404}},
+
+		{method: "GET", path: "/api/events/ws", tag: "events", summary: "Nhận sự kiện qua WebSocket, mỗi message là một Event",
+			query: eventQueryParams, status: http.StatusSwitchingProtocols, errors: []int{400}},
+		{method: "GET", path: "/api/events/sse", tag: "events", summary: "Nhận sự kiện qua Server-Sent Events, tên sự kiện là topic",
+			query: eventQueryParams, response: events.Event{}, contentTypes: []string{"text/event-stream"}, errors: []int{400}},
+
+		{method: "POST", path: "/api/staking/stake", tag: "staking", summary: "Stake coin, ký phía server",
+			request: models.StakeRequest{}, response: models.StakeResponse{}, errors: []int{400, 403, 500}},
+		{method: "POST", path: "/api/staking/unstake", tag: "staking", summary: "Rút toàn bộ stake, ký phía server",
+			request: models.UnstakeRequest{}, response: models.StakeResponse{}, errors: []int{400, 403, 500}},
+		{method: "POST", path: "/api/staking/stake/signed", tag: "staking", summary: "Gửi giao dịch stake đã ký",
+			request: pool.Transaction{}, response: models.StakeResponse{}, errors: []int{400}},
+		{method: "POST", path: "/api/staking/unstake/signed", tag: "staking", summary: "Gửi giao dịch unstake đã ký",
+			request: pool.Transaction{}, response: models.StakeResponse{}, errors: []int{400}},
+		{method: "GET", path: "/api/staking/validators", tag: "staking", summary: "Danh sách validator",
+			response: models.ValidatorsResponse{}},
+		{method: "GET", path: "/api/staking/validator/:address", tag: "staking", summary: "Thông tin một validator",
+			response: consensus.Validator{}, errors: []int{404}},
+		{method: "GET", path: "/api/staking/info", tag: "staking", summary: "Tham số và tổng stake của staking pool",
+			response: models.StakingInfoResponse{}},
+
+		{method: "GET", path: "/api/network/peers", tag: "network", summary: "Các peer P2P đang kết nối",
+			response: models.PeersResponse{}},
+
+		{method: "GET", path: "/api/admin/validate-chain", tag: "admin", summary: "Kiểm tra toàn bộ chain từ genesis",
+			response: models.ValidationResult{}, errors: []int{401, 403}, admin: true},
+
+		{method: "POST", path: "/api/transaction/send", tag: "transaction", summary: "Gửi coin, ký phía server",
+			request: models.SendTransactionRequest{}, response: models.TransactionSubmitResponse{}, errors: []int{400, 403, 500}},
+		{method: "POST", path: "/api/transaction/broadcast", tag: "transaction", summary: "Gửi giao dịch đã ký",
+			request: pool.Transaction{}, response: models.TransactionSubmitResponse{}, errors: []int{400}},
+		{method: "POST", path: "/api/transaction/replace", tag: "transaction", summary: "Thay giao dịch đang chờ bằng giao dịch phí cao hơn",
+			request: pool.Transaction{}, response: models.TransactionSubmitResponse{}, errors: []int{400}},
+		{method: "POST", path: "/api/transaction/cancel", tag: "transaction", summary: "Hủy giao dịch đang chờ, ký phía server",
+			request: models.CancelTransactionRequest{}, response: models.TransactionSubmitResponse{}, errors: []int{400, 403, 500}},
+		{method: "GET", path: "/api/transaction/history/:address", tag: "transaction", summary: "Lịch sử giao dịch có lọc và phân trang",
+			query: historyQueryParams, response: models.TransactionHistoryResponse{}, errors: []int{400}},
+		{method: "GET", path: "/api/transaction/history/:address/export", tag: "transaction", summary: "Xuất lịch sử đã xác nhận kèm số dư",
+			query:    []apiParam{{"format", "string", "csv (mặc định) hoặc json"}},
+			response: []*models.HistoryRecord{}, contentTypes: []string{"text/csv", "application/json"}, errors: []int{400}},
+		{method: "GET", path: "/api/transaction/:hash", tag: "transaction", summary: "Trạng thái giao dịch theo hash",
+			response: models.TransactionStatusResponse{}, errors: []int{404}},
+	}
+}
+
+// checkAPIRoutes đối chiếu các route /api và /rpc đã đăng ký với operations để tài liệu
+// OpenAPI luôn khớp với router
+func checkAPIRoutes(routes gin.RoutesInfo, operations []apiOperation) error {
+	documented := make(map[string]bool)
+	for _, operation := range operations {
+		documented[operation.method+" "+operation.path] = true
+	}
+
+	for _, route := range routes {
+		if route.Path != "/rpc" && !strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+		key := route.Method + " " + route.Path
+		if !documented[key] {
+			return fmt.Errorf("route %s is missing from the OpenAPI document", key)
+		}
+		delete(documented, key)
+	}
+
+	for key := range documented {
+		return fmt.Errorf("OpenAPI document describes unregistered route %s", key)
+	}
+	return nil
+}
+
+func (s *Server) getOpenAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, s.openAPI)
+}
+
+// buildOpenAPI dựng tài liệu OpenAPI 3; schema của body được sinh từ các kiểu Go
+// theo json tag
+func buildOpenAPI(operations []apiOperation) map[string]interface{} {
+	registry := &schemaRegistry{
+		schemas: make(map[string]interface{}),
+		types:   make(map[string]reflect.Type),
+	}
+	errorSchema := registry.schemaOf(reflect.TypeOf(models.ErrorResponse{}))
+
+	paths := make(map[string]map[string]interface{})
+	for _, operation := range operations {
+		var parameters []interface{}
+		segments := strings.Split(operation.path, "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, ":") {
+				segments[i] = "{" + segment[1:] + "}"
+				parameters = append(parameters, map[string]interface{}{
+					"name": segment[1:], "in": "path", "required": true,
+					"schema": map[string]interface{}{"type": "string"},
+				})
+			}
+		}
+		for _, param := range operation.query {
+			parameters = append(parameters, map[string]interface{}{
+				"name": param.name, "in": "query", "description": param.description,
+				"schema": map[string]interface{}{"type": param.typ},
+			})
+		}
+		if operation.admin {
+			parameters = append(parameters, map[string]interface{}{
+				"name": "X-Admin-Token", "in": "header",
+				"description": "Bắt buộc khi node cấu hình AdminToken, ngược lại chỉ gọi được từ localhost",
+				"schema":      map[string]interface{}{"type": "string"},
+			})
+		}
+
+		status := operation.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := map[string]interface{}{"description": http.StatusText(status)}
+		if operation.response != nil {
+			schema := registry.schemaOf(reflect.TypeOf(operation.response))
+			if operation.batch {
+				schema = batchSchema(schema)
+			}
+			contentTypes := operation.contentTypes
+			if len(contentTypes) == 0 {
+				contentTypes = []string{"application/json"}
+			}
+			content := make(map[string]interface{})
+			for _, contentType := range contentTypes {
+				if contentType == "application/json" {
+					content[contentType] = map[string]interface{}{"schema": schema}
+				} else {
+					content[contentType] = map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}
+				}
+			}
+			success["content"] = content
+		}
+		responses := map[string]interface{}{strconv.Itoa(status): success}
+		if operation.noContent {
+			responses["204"] = map[string]interface{}{"description": "Request chỉ gồm notification"}
+		}
+		for _, code := range operation.errors {
+			responses[strconv.Itoa(code)] = map[string]interface{}{
+				"description": http.StatusText(code),
+				"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": errorSchema}},
+			}
+		}
+
+		entry := map[string]interface{}{
+			"tags":        []string{operation.tag},
+			"summary":     operation.summary,
+			"operationId": operationID(operation),
+			"responses":   responses,
+		}
+		if len(parameters) > 0 {
+			entry["parameters"] = parameters
+		}
+		if operation.request != nil {
+			schema := registry.schemaOf(reflect.TypeOf(operation.request))
+			if operation.batch {
+				schema = batchSchema(schema)
+			}
+			entry["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}},
+			}
+		}
+
+		openAPIPath := strings.Join(segments, "/")
+		if paths[openAPIPath] == nil {
+			paths[openAPIPath] = make(map[string]interface{})
+		}
+		paths[openAPIPath][strings.ToLower(operation.method)] = entry
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "MyCoin Node API",
+			"version":     "1.0.0",
+			"description": "REST API và JSON-RPC của node MyCoin. Số coin là số MYC với tối đa 8 chữ số thập phân.",
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": registry.schemas},
+	}
+}
+
+func batchSchema(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"oneOf": []interface{}{schema, map[string]interface{}{"type": "array", "items": schema}},
+	}
+}
+
+// operationID ghép method và các đoạn cố định của path, ví dụ getApiWalletBalance
+func operationID(operation apiOperation) string {
+	id := strings.ToLower(operation.method)
+	for _, segment := range strings.FieldsFunc(operation.path, func(r rune) bool {
+		return r == '/' || r == '-' || r == '.'
+	}) {
+		if strings.HasPrefix(segment, ":") {
+			segment = "by-" + segment[1:]
+		}
+		for _, word := range strings.Split(segment, "-") {
+			if word != "" {
+				id += strings.ToUpper(word[:1]) + word[1:]
+			}
+		}
+	}
+	return id
+}
+
+var (
+	amountType     = reflect.TypeOf(coin.Amount(0))
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// schemaRegistry sinh JSON schema từ kiểu Go; struct có tên được đưa vào
+// components/schemas và tham chiếu bằng $ref
+type schemaRegistry struct {
+	schemas map[string]interface{}
+	types   map[string]reflect.Type
+}
+
+func (r *schemaRegistry) schemaOf(t reflect.Type) map[string]interface{} {
+	switch t {
+	case amountType:
+		return map[string]interface{}{"type": "number", "description": "Số MYC, tối đa 8 chữ số thập phân"}
+	case rawMessageType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return r.schemaOf(t.Elem())
+	case reflect.Interface:
+		return map[string]interface{}{}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64", "minimum": 0}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": r.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": r.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return map[string]interface{}{"$ref": "#/components/schemas/" + r.register(t)}
+	}
+	return map[string]interface{}{}
+}
+
+// register thêm schema của struct t vào components và trả về tên schema; struct cùng
+// tên ở package khác được đặt tên kèm package
+func (r *schemaRegistry) register(t reflect.Type) string {
+	name := t.Name()
+	if existing, exists := r.types[name]; exists && existing != t {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	if _, exists := r.types[name]; exists {
+		return name
+	}
+	r.types[name] = t
+
+	properties := make(map[string]interface{})
+	var required []string
+	r.addFields(t, properties, &required)
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	r.schemas[name] = schema
+	return name
+}
+
+// addFields thêm các trường được mã hóa JSON của t; struct nhúng không có json tag
+// được gộp vào như encoding/json
+func (r *schemaRegistry) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if field.Anonymous && tag == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			r.addFields(embedded, properties, required)
+			continue
+		}
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = r.schemaOf(field.Type)
+		if !strings.Contains(options, "omitempty") && field.Type.Kind() != reflect.Ptr {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package api
+
+import (
+	"MyCoinApp/internal/models"
+	"MyCoinApp/internal/pool"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Mã lỗi của JSON-RPC 2.0; rpcServerError dùng cho lỗi của chính phương thức
+// (giao dịch bị từ chối, không tìm thấy block...)
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+)
+
+// maxRPCBatch là số request tối đa trong một batch
+const maxRPCBatch = 100
+
+func rpcErrorf(code int, format string, args ...interface{}) *models.RPCError {
+	return &models.RPCError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// rpcMethod xử lý params của một request; trả về *models.RPCError để chọn mã lỗi,
+// lỗi khác được trả về với mã rpcServerError
+type rpcMethod func(params json.RawMessage) (interface{}, error)
+
+func (s *Server) rpcMethods() map[string]rpcMethod {
+	return map[string]rpcMethod{
+		"getBalance": func(params json.RawMessage) (interface{}, error) {
+			var address string
+			if err := decodeRPCParams(params, []string{"address"}, &address); err != nil {
+				return nil, err
+			}
+			return s.balance(address), nil
+		},
+		"getNonce": func(params json.RawMessage) (interface{}, error) {
+			var address string
+			if err := decodeRPCParams(params, []string{"address"}, &address); err != nil {
+				return nil, err
+			}
+			return s.nonce(address), nil
+		},
+		"sendRawTransaction": func(params json.RawMessage) (interface{}, error) {
+			var raw json.RawMessage
+			if err := decodeRPCParams(params, []string{"transaction"}, &raw); err != nil {
+				return nil, err
+			}
+			tx, err := decodeRawTransaction(raw)
+			if err != nil {
+				return nil, err
+			}
+			if err := s.broadcast(tx); err != nil {
+				return nil, err
+			}
+			return tx.Hash, nil
+		},
+		"getBlockByNumber": func(params json.RawMessage) (interface{}, error) {
+			var number json.RawMessage
+			if err := decodeRPCParams(params, []string{"number"}, &number); err != nil {
+				return nil, err
+			}
+			index, err := s.parseBlockNumber(number)
+			if err != nil {
+				return nil, err
+			}
+			return s.blockchain.GetBlockByIndex(index)
+		},
+		"getTransaction": func(params json.RawMessage) (interface{}, error) {
+			var hash string
+			if err := decodeRPCParams(params, []string{"hash"}, &hash); err != nil {
+				return nil, err
+			}
+			status, found := s.blockchain.GetTransactionStatus(hash)
+			if !found {
+				return nil, fmt.Errorf("transaction %s not found", hash)
+			}
+			return status, nil
+		},
+		"getValidators": func(params json.RawMessage) (interface{}, error) {
+			return s.validators(), nil
+		},
+		"getStakingInfo": func(params json.RawMessage) (interface{}, error) {
+			return s.blockchain.GetStakingInfo(), nil
+		},
+	}
+}
+
+// decodeRPCParams đọc params dạng mảng theo vị trí hoặc object theo tên trong names
+func decodeRPCParams(params json.RawMessage, names []string, targets ...interface{}) error {
+	params = bytes.TrimSpace(params)
+	if len(params) == 0 {
+		return rpcErrorf(rpcInvalidParams, "missing params: %v", names)
+	}
+
+	values := make([]json.RawMessage, len(names))
+	switch params[0] {
+	case '[':
+		var list []json.RawMessage
+		if err := json.Unmarshal(params, &list); err != nil {
+			return rpcErrorf(rpcInvalidParams, "invalid params: %v", err)
+		}
+		if len(list) != len(names) {
+			return rpcErrorf(rpcInvalidParams, "expected %d params, got %d", len(names), len(list))
+		}
+		copy(values, list)
+	case '{':
+		var named map[string]json.RawMessage
+		if err := json.Unmarshal(params, &named); err != nil {
+			return rpcErrorf(rpcInvalidParams, "invalid params: %v", err)
+		}
+		for i, name := range names {
+			value, ok := named[name]
+			if !ok {
+				return rpcErrorf(rpcInvalidParams, "missing param %q", name)
+			}
+			values[i] = value
+		}
+	default:
+		return rpcErrorf(rpcInvalidParams, "params must be an array or an object")
+	}
+
+	for i, value := range values {
+		if raw, ok := targets[i].(*json.RawMessage); ok {
+			*raw = value
+			continue
+		}
+		if err := json.Unmarshal(value, targets[i]); err != nil {
+			return rpcErrorf(rpcInvalidParams, "invalid param %q: %v", names[i], err)
+		}
+	}
+	return nil
+}
+
+// decodeRawTransaction nhận giao dịch dạng chuỗi hex của bản mã hóa nhị phân đầy đủ
+// (pool.Transaction.MarshalBinary) hoặc dạng object JSON như /api/transaction/broadcast
+func decodeRawTransaction(raw json.RawMessage) (*pool.Transaction, error) {
+	var encoded string
+	if err := json.Unmarshal(raw, &encoded); err == nil {
+		data, err := hex.DecodeString(encoded)
+		if err != nil {
+			return nil, rpcErrorf(rpcInvalidParams, "transaction must be hex encoded")
+		}
+		tx, err := pool.DecodeTransaction(data)
+		if err != nil {
+			return nil, rpcErrorf(rpcInvalidParams, "invalid transaction: %v", err)
+		}
+		return tx, nil
+	}
+
+	var tx pool.Transaction
+	if err := json.Unmarshal(raw, &tx); err != nil {
+		return nil, rpcErrorf(rpcInvalidParams, "invalid transaction: %v", err)
+	}
+	return &tx, nil
+}
+
+// parseBlockNumber nhận index block dạng số, chuỗi số hoặc "latest"
+func (s *Server) parseBlockNumber(raw json.RawMessage) (int64, error) {
+	var index int64
+	if err := json.Unmarshal(raw, &index); err == nil {
+		return index, nil
+	}
+
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return 0, rpcErrorf(rpcInvalidParams, "block number must be an integer or \"latest\"")
+	}
+	if value == "latest" {
+		return s.blockchain.Height() - 1, nil
+	}
+	index, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, rpcErrorf(rpcInvalidParams, "block number must be an integer or \"latest\"")
+	}
+	return index, nil
+}
+
+// handleRPC xử lý request JSON-RPC 2.0 đơn lẻ hoặc batch
+func (s *Server) handleRPC(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.RPCResponse{JSONRPC: "2.0", Error: rpcErrorf(rpcParseError, "failed to read request"), ID: json.RawMessage("null")})
+		return
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			c.JSON(http.StatusOK, models.RPCResponse{JSONRPC: "2.0", Error: rpcErrorf(rpcParseError, "parse error"), ID: json.RawMessage("null")})
+			return
+		}
+		if len(batch) == 0 || len(batch) > maxRPCBatch {
+			c.JSON(http.StatusOK, models.RPCResponse{JSONRPC: "2.0", Error: rpcErrorf(rpcInvalidRequest, "batch must contain 1 to %d requests", maxRPCBatch), ID: json.RawMessage("null")})
+			return
+		}
+
+		methods := s.rpcMethods()
+		responses := make([]*models.RPCResponse, 0, len(batch))
+		for _, message := range batch {
+			if response := s.callRPC(methods, message); response != nil {
+				responses = append(responses, response)
+			}
+		}
+		// Batch chỉ gồm notification thì không có gì để trả về
+		if len(responses) == 0 {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		c.JSON(http.StatusOK, responses)
+		return
+	}
+
+	response := s.callRPC(s.rpcMethods(), body)
+	if response == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// callRPC thực thi một request; trả về nil nếu request là notification (không có id)
+func (s *Server) callRPC(methods map[string]rpcMethod, message json.RawMessage) *models.RPCResponse {
+	var request models.RPCRequest
+	if err := json.Unmarshal(message, &request); err != nil {
+		if _, ok := err.(*json.SyntaxError); ok {
+			return &models.RPCResponse{JSONRPC: "2.0", Error: rpcErrorf(rpcParseError, "parse error"), ID: json.RawMessage("null")}
+		}
+		return &models.RPCResponse{JSONRPC: "2.0", Error: rpcErrorf(rpcInvalidRequest, "invalid request"), ID: json.RawMessage("null")}
+	}
+
+	id := request.ID
+	notification := len(id) == 0
+	if notification {
+		id = json.RawMessage("null")
+	}
+
+	if request.JSONRPC != "2.0" || request.Method == "" {
+		return &models.RPCResponse{JSONRPC: "2.0", Error: rpcErrorf(rpcInvalidRequest, "invalid request"), ID: id}
+	}
+
+	method, exists := methods[request.Method]
+	if !exists {
+		if notification {
+			return nil
+		}
+		return &models.RPCResponse{JSONRPC: "2.0", Error: rpcErrorf(rpcMethodNotFound, "method %q not found", request.Method), ID: id}
+	}
+
+	result, err := method(request.Params)
+	if notification {
+		return nil
+	}
+	if err != nil {
+		rpcErr, ok := err.(*models.RPCError)
+		if !ok {
+			rpcErr = &models.RPCError{Code: rpcServerError, Message: err.Error()}
+		}
+		return &models.RPCResponse{JSONRPC: "2.0", Error: rpcErr, ID: id}
+	}
+	return &models.RPCResponse{JSONRPC: "2.0", Result: result, ID: id}
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"MyCoinApp/internal/models"
+	"MyCoinApp/internal/pool"
+	"log"
+)
+
+// Các hàm dưới đây là phần dùng chung giữa REST handler và JSON-RPC (xem rpc.go),
+// để hai giao diện luôn trả về cùng dữ liệu và áp dụng cùng kiểm tra.
+
+func (s *Server) balance(address string) *models.BalanceResponse {
+	return &models.BalanceResponse{
+		Address:   address,
+		Balance:   s.blockchain.GetBalance(address),
+		Available: s.blockchain.GetAvailableBalance(address),
+	}
+}
+
+func (s *Server) nonce(address string) *models.NonceResponse {
+	return &models.NonceResponse{
+		Address:        address,
+		Nonce:          s.blockchain.GetNextNonce(address),
+		ConfirmedNonce: s.blockchain.GetConfirmedNonce(address),
+	}
+}
+
+func (s *Server) validators() *models.ValidatorsResponse {
+	validators := s.blockchain.GetValidators()
+	return &models.ValidatorsResponse{
+		Validators: validators,
+		Count:      len(validators),
+	}
+}
+
+// broadcast đưa giao dịch đã ký đầy đủ ở phía client vào pending pool
+func (s *Server) broadcast(tx *pool.Transaction) error {
+	log.Printf("Broadcast transaction: Hash=%s, From=%s, To=%s, Amount=%s, Fee=%s",
+		tx.Hash, tx.From, tx.To, tx.Amount, tx.Fee)
+
+	if err := s.blockchain.AddTransaction(tx); err != nil {
+		log.Printf("Error adding broadcast transaction: %v", err)
+		return err
+	}
+	return nil
+}
@@ -2,57 +2,112 @@ package blockchain
 
 import (
 	"MyCoinApp/internal/pool"
+	"MyCoinApp/internal/wallet"
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
-	"strconv"
+	"fmt"
 	"time"
 )
 
 type Block struct {
+	// Version là phiên bản mã hóa chuẩn của header (xem BlockVersion1)
+	Version      uint8               `json:"version"`
 	Index        int64               `json:"index"`
 	Timestamp    int64               `json:"timestamp"`
 	Transactions []*pool.Transaction `json:"transactions"`
-	PreviousHash string              `json:"previous_hash"`
-	Hash         string              `json:"hash"`
+	// MerkleRoot là Merkle root của hash các giao dịch, được tính vào hash của block
+	MerkleRoot   string `json:"merkle_root"`
+	PreviousHash string `json:"previous_hash"`
+	Hash         string `json:"hash"`
+	// Validator là địa chỉ validator tạo block, được tính vào hash của block
+	Validator string `json:"validator"`
+	// ValidatorPublicKey và Signature là chữ ký của validator trên Hash
+	ValidatorPublicKey string `json:"validator_public_key"`
+	Signature          string `json:"signature"`
 }
 
 func NewBlock(transactions []*pool.Transaction, previousHash string, validator string, blockNumber int64) *Block {
 	block := &Block{
+		Version:      CurrentBlockVersion,
 		Index:        blockNumber,
 		Timestamp:    time.Now().Unix(),
 		Transactions: transactions,
 		PreviousHash: previousHash,
+		Validator:    validator,
 	}
 
+	// Giao dịch đưa vào block đã được kiểm tra hash nên không thể lỗi
+	block.MerkleRoot, _ = ComputeMerkleRoot(block.Version, transactions)
+
 	// Calculate hash directly (no mining)
-	block.Hash = block.CalculateHashPOS(validator)
+	block.Hash = block.CalculateHash()
 	return block
 }
 
-func (b *Block) CalculateHashPOS(validator string) string {
-	data := strconv.FormatInt(b.Index, 10) +
-		strconv.FormatInt(b.Timestamp, 10) +
-		b.PreviousHash +
-		validator
-	for _, tx := range b.Transactions {
-		txBytes, _ := json.Marshal(tx)
-		data += string(txBytes)
+// CalculateHash tính sha256 của bản mã hóa chuẩn của header; giao dịch được tính
+// gián tiếp qua MerkleRoot. Trả về chuỗi rỗng nếu Version không được hỗ trợ.
+func (b *Block) CalculateHash() string {
+	data, err := b.EncodeHeader()
+	if err != nil {
+		return ""
 	}
 
-	hash := sha256.Sum256([]byte(data))
+	hash := sha256.Sum256(data)
 	return hex.EncodeToString(hash[:])
 }
 
-func (b *Block) CalculateHash() string {
-	data := strconv.FormatInt(b.Index, 10) +
-		strconv.FormatInt(b.Timestamp, 10) +
-		b.PreviousHash
-	for _, tx := range b.Transactions {
-		txBytes, _ := json.Marshal(tx)
-		data += string(txBytes)
+// VerifyMerkleRoot kiểm tra MerkleRoot khớp với các giao dịch trong block
+func (b *Block) VerifyMerkleRoot() error {
+	merkleRoot, err := ComputeMerkleRoot(b.Version, b.Transactions)
+	if err != nil {
+		return fmt.Errorf("block %d: %v", b.Index, err)
 	}
+	if merkleRoot != b.MerkleRoot {
+		return fmt.Errorf("block %d has an invalid merkle root", b.Index)
+	}
+	return nil
+}
 
-	hash := sha256.Sum256([]byte(data))
-	return hex.EncodeToString(hash[:])
+// Sign ký hash của block bằng ví của validator tạo block
+func (b *Block) Sign(validatorWallet *wallet.Wallet) error {
+	if validatorWallet.Address != b.Validator {
+		return fmt.Errorf("wallet %s is not the producer of block %d", validatorWallet.Address, b.Index)
+	}
+
+	hashBytes, err := hex.DecodeString(b.Hash)
+	if err != nil {
+		return err
+	}
+
+	signature, err := validatorWallet.SignHash(hashBytes)
+	if err != nil {
+		return err
+	}
+
+	b.ValidatorPublicKey = validatorWallet.GetPublicKeyHex()
+	b.Signature = signature
+	return nil
+}
+
+// VerifySignature kiểm tra block được ký bởi chính validator ghi trong block
+func (b *Block) VerifySignature() error {
+	if b.Signature == "" || b.ValidatorPublicKey == "" {
+		return fmt.Errorf("block %d is not signed", b.Index)
+	}
+
+	hashBytes, err := hex.DecodeString(b.Hash)
+	if err != nil {
+		return fmt.Errorf("block %d has an invalid hash", b.Index)
+	}
+
+	signer, err := wallet.VerifyHash(b.ValidatorPublicKey, hashBytes, b.Signature)
+	if err != nil {
+		return fmt.Errorf("block %d: %v", b.Index, err)
+	}
+
+	if signer != b.Validator {
+		return fmt.Errorf("block %d is signed by %s instead of validator %s", b.Index, signer, b.Validator)
+	}
+
+	return nil
 }
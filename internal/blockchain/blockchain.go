@@ -1,9 +1,12 @@
 package blockchain
 
 import (
+	"MyCoinApp/internal/coin"
 	"MyCoinApp/internal/consensus"
+	"MyCoinApp/internal/events"
 	"MyCoinApp/internal/models"
 	"MyCoinApp/internal/pool"
+	"MyCoinApp/internal/wallet"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -13,49 +16,143 @@ import (
 	"time"
 )
 
-type Blockchain struct {
-	Chain               []*Block            `json:"chain"`
-	PendingTransactions []*pool.Transaction `json:"pending_transactions"`
-	MiningReward        float64             `json:"mining_reward"`
+// maxBlockTransactions là số giao dịch người dùng tối đa trong một block
+const maxBlockTransactions = 500
+
+const (
+	// validatorCooldown là số giây tối thiểu giữa timestamp của hai block do cùng một validator tạo
+	validatorCooldown = 60
+	// maxFutureBlockTime là số giây tối đa timestamp của block được vượt quá đồng hồ của node
+	maxFutureBlockTime = 15
+)
 
-	Balances map[string]float64 `json:"balances"`
+type Blockchain struct {
+	// Version là phiên bản định dạng của blockchain.json (xem snapshotVersion)
+	Version int      `json:"version"`
+	Chain   []*Block `json:"chain"`
+	// TxPool là mempool chứa các giao dịch đang chờ vào block
+	TxPool       *pool.TransactionPool `json:"-"`
+	MiningReward coin.Amount           `json:"mining_reward"`
+
+	// Balances, Nonces và StakingPool được dựng lại từ Chain khi khởi động;
+	// giá trị lưu trong blockchain.json chỉ dùng để đối chiếu
+	Balances map[string]coin.Amount `json:"balances"`
+	// Nonces lưu nonce kế tiếp được chấp nhận (đã xác nhận trong chain) của mỗi địa chỉ
+	Nonces map[string]uint64 `json:"nonces"`
 
 	StakingPool *consensus.StakingPool `json:"staking_pool"`
 	mutex       sync.RWMutex           `json:"-"`
+
+	// genesis là genesis block chuẩn của node, chain nạp từ file phải bắt đầu bằng block này
+	genesis *Block
+	// feePolicy là cách chia phí giao dịch của chain, không được đổi khi chain đã có block
+	feePolicy consensus.FeePolicy
+
+	// blockIndex (hash → index block), txIndex (hash → vị trí của giao dịch đã xác nhận)
+	// và addressIndex (địa chỉ → vị trí các giao dịch gửi/nhận) được cập nhật khi nối block
+	blockIndex   map[string]int64
+	txIndex      map[string]txLocation
+	addressIndex map[string][]txLocation
+	// dropped ghi lại các giao dịch gần đây bị loại khỏi pool hoặc bị từ chối
+	dropped *droppedLog
+	// weights là weight tích lũy của từng block trong Chain, sideBlocks là các block hợp lệ
+	// ngoài chain chính theo hash và finalized là index của checkpoint final. forkStates là
+	// state sau các block của chain chính mà nhánh phụ rẽ ra, theo hash (xem fork.go).
+	weights    []coin.Amount
+	sideBlocks map[string]*sideBlock
+	forkStates map[string]*chainState
+	finalized  int64
+	// events phát sự kiện block, giao dịch, validator và số dư cho client
+	events *events.Bus
+	// validity là kết quả IsChainValid gần nhất
+	validity validityCache
+	// migration là số coin ví faucet còn phải chuyển cho các tài khoản của blockchain.json
+	// phiên bản cũ (xem migrate.go)
+	migration map[string]coin.Amount
+	// legacyHeight là migration point của chain: chỉ block có Index nhỏ hơn mới được dùng
+	// BlockVersion1 và luật thưởng cũ, vì chúng được tạo trước khi node nâng cấp
+	legacyHeight int64
+}
+
+// validityCache lưu kết quả IsChainValid của chain có block mới nhất là tip.
+// Có mutex riêng vì IsChainValid chỉ giữ read lock của chain.
+type validityCache struct {
+	mutex sync.Mutex
+	tip   string
+	valid bool
 }
 
-func NewBlockchain() *Blockchain {
+// NewBlockchain tạo chain với genesis block cấp toàn bộ coin ban đầu cho genesisAddress,
+// sau đó nạp blockchain.json (nếu có) và dựng lại state từ các block đã lưu.
+// Trả về lỗi nếu file không hợp lệ hoặc state lưu trong file không khớp với chain.
+func NewBlockchain(genesisAddress string, feePolicy consensus.FeePolicy, txPool *pool.TransactionPool, bus *events.Bus) (*Blockchain, error) {
+	if err := feePolicy.Validate(); err != nil {
+		return nil, err
+	}
+
 	bc := &Blockchain{
-		Chain:               []*Block{},
-		PendingTransactions: []*pool.Transaction{},
-		MiningReward:        50.0, // Default mining reward
+		Version:      snapshotVersion,
+		Chain:        []*Block{},
+		TxPool:       txPool,
+		MiningReward: 50 * coin.Unit, // Default mining reward
 
-		Balances:    make(map[string]float64),
+		Balances:    make(map[string]coin.Amount),
+		Nonces:      make(map[string]uint64),
 		StakingPool: consensus.NewStakingPool(),
+		feePolicy:   feePolicy,
+		dropped:     newDroppedLog(),
+		events:      bus,
+		sideBlocks:  make(map[string]*sideBlock),
+		forkStates:  make(map[string]*chainState),
 	}
 
-	bc.CreateGenesisBlock()
-	bc.LoadFromFile()
-	return bc
+	bc.CreateGenesisBlock(genesisAddress)
+	if err := bc.LoadFromFile(); err != nil {
+		return nil, err
+	}
+	return bc, nil
 }
 
 // CreateGenesisBlock tạo block đầu tiên (genesis block) của blockchain
-// Genesis block là block không có previous hash và chứa coin ban đầu
-func (bc *Blockchain) CreateGenesisBlock() {
+// Genesis block là block không có previous hash và chứa coin ban đầu: toàn bộ
+// genesisAllocation được cấp cho genesisAddress, trong đó genesisValidatorStake được
+// stake sẵn để genesisAddress là validator đầu tiên của chain.
+func (bc *Blockchain) CreateGenesisBlock(genesisAddress string) {
 	// Tạo genesis block với thông tin cơ bản
+	// Genesis block luôn dùng BlockVersion1 để hash của genesis không đổi
 	genesisBlock := &Block{
-		Index:        0,
-		Timestamp:    1609459200,
-		Transactions: []*pool.Transaction{},
+		Version:   BlockVersion1,
+		Index:     0,
+		Timestamp: genesisTimestamp,
+		Transactions: []*pool.Transaction{
+			pool.NewGenesisTransaction(genesisAddress, genesisAllocation, genesisTimestamp),
+			newGenesisStakeTransaction(genesisAddress),
+		},
 		PreviousHash: "0",
-		Hash:         "0",
 	}
-	bc.Chain = append(bc.Chain, genesisBlock)
-	bc.Balances["genesis"] = 1000000.0
+	genesisBlock.MerkleRoot, _ = ComputeMerkleRoot(genesisBlock.Version, genesisBlock.Transactions)
+	genesisBlock.Hash = genesisBlock.CalculateHash()
+
+	state := newChainState(bc.feePolicy)
+	if err := state.applyBlock(genesisBlock); err != nil {
+		// Genesis block được dựng từ hằng số nên không thể lỗi
+		panic(err)
+	}
+
+	bc.genesis = genesisBlock
+	bc.Chain = []*Block{genesisBlock}
+	bc.weights = []coin.Amount{0}
+	bc.setState(state)
+	bc.reindexTransactions()
 }
 
 func (bc *Blockchain) SaveToFile() error {
-	data, err := json.MarshalIndent(bc, "", "  ")
+	snap, err := bc.toSnapshot()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(snap, "", "  ")
 	if err != nil {
 		return err
 	}
@@ -63,6 +160,9 @@ func (bc *Blockchain) SaveToFile() error {
 	return ioutil.WriteFile("blockchain.json", data, 0644)
 }
 
+// LoadFromFile nạp blockchain.json, dựng lại Balances, Nonces và StakingPool bằng cách
+// replay chain từ genesis và từ chối file nếu state lưu trong file khác với kết quả replay.
+// File có chain không replay được được chuyển sang chain mới (xem migrateLegacySnapshot).
 func (bc *Blockchain) LoadFromFile() error {
 	if _, err := os.Stat("blockchain.json"); os.IsNotExist(err) {
 		return nil
@@ -73,46 +173,121 @@ func (bc *Blockchain) LoadFromFile() error {
 		return err
 	}
 
-	return json.Unmarshal(data, bc)
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return err
+	}
+
+	if err := checkSnapshotVersion(header.Version); err != nil {
+		return err
+	}
+	if header.Version < replayableSnapshotVersion {
+		return bc.migrateLegacySnapshot(data, header.Version)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+
+	if err := bc.restoreSnapshot(&snap); err != nil {
+		return fmt.Errorf("blockchain.json rejected: %v", err)
+	}
+
+	if err := bc.rebuildState(); err != nil {
+		return fmt.Errorf("blockchain.json rejected: %v", err)
+	}
+
+	return nil
 }
 
-func (bc *Blockchain) AddBalance(address string, amount float64) {
-	bc.mutex.Lock()
-	defer bc.mutex.Unlock()
-	bc.Balances[address] += amount
-	log.Printf("Balance of %s updated to %.2f", address, bc.Balances[address])
-	bc.SaveToFile()
+// rebuildState replay chain từ genesis và thay state hiện tại bằng kết quả replay
+// sau khi đối chiếu với state đang có (state nạp từ file). State sau checkpoint final được
+// giữ lại trong forkStates trong lúc replay.
+func (bc *Blockchain) rebuildState() error {
+	finalized := finalizedCheckpoint(int64(len(bc.Chain)) - 1)
+	state, result := bc.replayChain(func(block *Block, state *chainState) {
+		if block.Index == finalized {
+			bc.forkStates[block.Hash] = state.clone()
+		}
+	})
+	if !result.Valid {
+		return fmt.Errorf("invalid block %d (%s): %s", result.InvalidBlock, result.InvalidHash, result.Reason)
+	}
+
+	if err := state.matches(bc.currentState()); err != nil {
+		return fmt.Errorf("stored state disagrees with the chain: %v", err)
+	}
+
+	bc.setState(state)
+	bc.reindexTransactions()
+	return bc.reweighChain()
 }
 
-func (bc *Blockchain) GetBalance(address string) float64 {
+// setState thay Balances, Nonces và StakingPool bằng state đã được kiểm tra
+func (bc *Blockchain) setState(state *chainState) {
+	bc.Balances = state.Balances
+	bc.Nonces = state.Nonces
+	bc.StakingPool = state.StakingPool
+}
+
+// currentState trả về state hiện tại của chain (dùng chung map, không sao chép)
+func (bc *Blockchain) currentState() *chainState {
+	return &chainState{Balances: bc.Balances, Nonces: bc.Nonces, StakingPool: bc.StakingPool}
+}
+
+func (bc *Blockchain) GetBalance(address string) coin.Amount {
 	bc.mutex.RLock()
 	defer bc.mutex.RUnlock()
 	// Tìm balance trong map
 	balance, exists := bc.Balances[address]
 	if !exists {
-		return 0.0
+		return 0
 	}
 	return balance
 }
 
+// IsChainValid kiểm tra liên kết, hash, chữ ký và validator của các block. Kết quả được
+// lưu theo hash của block mới nhất: khi chain chỉ nối thêm block, chỉ các block mới được kiểm tra.
 func (bc *Blockchain) IsChainValid() bool {
 	bc.mutex.RLock()
 	defer bc.mutex.RUnlock()
 
-	for i := 1; i < len(bc.Chain); i++ {
+	bc.validity.mutex.Lock()
+	defer bc.validity.mutex.Unlock()
+
+	tip := bc.Chain[len(bc.Chain)-1]
+	if bc.validity.tip == tip.Hash {
+		return bc.validity.valid
+	}
+
+	// Block mới nhất đã kiểm tra còn trong chain chính thì các block đến nó vẫn hợp lệ
+	start := int64(1)
+	if index, exists := bc.blockIndex[bc.validity.tip]; exists && bc.validity.valid {
+		start = index + 1
+	}
+
+	valid := true
+	for i := start; i < int64(len(bc.Chain)); i++ {
 		currentBlock := bc.Chain[i]
 		previousBlock := bc.Chain[i-1]
 
-		if currentBlock.Hash != currentBlock.CalculateHash() {
-			return false
+		if currentBlock.PreviousHash != previousBlock.Hash {
+			valid = false
+			break
 		}
 
-		if currentBlock.PreviousHash != previousBlock.Hash {
-			return false
+		if err := bc.validateBlockProducer(currentBlock, bc.StakingPool); err != nil {
+			log.Printf("Chain validation failed: %v", err)
+			valid = false
+			break
 		}
 	}
 
-	return true
+	bc.validity.tip, bc.validity.valid = tip.Hash, valid
+	return valid
 }
 
 func (bc *Blockchain) GetLatestBlock() *Block {
@@ -132,72 +307,520 @@ func (bc *Blockchain) GetValidators() []*consensus.Validator {
 	return bc.StakingPool.GetAllValidators()
 }
 
-func (bc *Blockchain) GetTransactionHistoryWithBlocks(address string) []*models.TransactionWithBlock {
+// SelectValidator chọn ngẫu nhiên một validator theo stake trong state của chain hiện tại
+func (bc *Blockchain) SelectValidator() (string, error) {
 	bc.mutex.RLock()
 	defer bc.mutex.RUnlock()
 
+	return bc.StakingPool.SelectValidator()
+}
+
+// GetTransactionHistoryWithBlocks trả về toàn bộ giao dịch đã xác nhận của address
+// theo thứ tự trong chain
+func (bc *Blockchain) GetTransactionHistoryWithBlocks(address string) []*models.TransactionWithBlock {
 	var transactions []*models.TransactionWithBlock
+	// fn không trả về lỗi nên EachTransactionWithBlock không thể lỗi
+	_ = bc.EachTransactionWithBlock(address, func(tx *models.TransactionWithBlock) error {
+		transactions = append(transactions, tx)
+		return nil
+	})
+	return transactions
+}
 
-	for _, block := range bc.Chain {
-		for _, tx := range block.Transactions {
-			if tx.From == address || tx.To == address {
-				txWithBlock := &models.TransactionWithBlock{
-					Transaction: tx,
-					BlockIndex:  block.Index,
-					BlockHash:   block.Hash,
-				}
-				transactions = append(transactions, txWithBlock)
-			}
+func (bc *Blockchain) AddTransaction(transaction *pool.Transaction) error {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	if err := bc.addTransaction(transaction); err != nil {
+		bc.recordDropped(transaction, models.TxStatusRejected, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (bc *Blockchain) addTransaction(transaction *pool.Transaction) error {
+	if err := bc.checkTransaction(transaction, nil); err != nil {
+		return err
+	}
+
+	// Nonce phải nối tiếp các giao dịch đã xác nhận và đang chờ của người gửi
+	expectedNonce := bc.nextNonce(transaction.From)
+	if transaction.Nonce < expectedNonce {
+		return fmt.Errorf("nonce %d already used, next nonce is %d", transaction.Nonce, expectedNonce)
+	}
+	if transaction.Nonce > expectedNonce {
+		return fmt.Errorf("nonce %d is too high, next nonce is %d", transaction.Nonce, expectedNonce)
+	}
+
+	// Thêm transaction vào pending pool
+	evicted, err := bc.TxPool.AddTransaction(transaction)
+	if err != nil {
+		return err
+	}
+	if evicted != nil {
+		log.Printf("Transaction pool is full, evicted %s (fee %s MYC)", evicted.Hash, evicted.Fee)
+		bc.recordDropped(evicted, models.TxStatusDropped,
+			fmt.Sprintf("evicted from full transaction pool by %s", transaction.Hash))
+	}
+	bc.publishTransaction(eventTransactionPending, transaction, models.TxStatusPending, "")
+	return nil // Thành công
+}
+
+// ReplaceTransaction thay giao dịch đang chờ có cùng người gửi và nonce bằng transaction
+// có phí cao hơn (replace-by-fee). Trả về giao dịch bị thay thế.
+func (bc *Blockchain) ReplaceTransaction(transaction *pool.Transaction) (*pool.Transaction, error) {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	existing, found := bc.TxPool.GetByNonce(transaction.From, transaction.Nonce)
+	if !found {
+		return nil, fmt.Errorf("no pending transaction from %s with nonce %d", transaction.From, transaction.Nonce)
+	}
+
+	if err := bc.checkTransaction(transaction, existing); err != nil {
+		bc.recordDropped(transaction, models.TxStatusRejected, err.Error())
+		return nil, err
+	}
+
+	replaced, err := bc.TxPool.ReplaceTransaction(transaction)
+	if err != nil {
+		bc.recordDropped(transaction, models.TxStatusRejected, err.Error())
+		return nil, err
+	}
+	bc.recordDropped(replaced, models.TxStatusDropped, fmt.Sprintf("replaced by %s", transaction.Hash))
+	bc.publishTransaction(eventTransactionReplaced, transaction, models.TxStatusPending, fmt.Sprintf("replaces %s", replaced.Hash))
+	return replaced, nil
+}
+
+// checkTransaction kiểm tra chữ ký, điều kiện stake/unstake và số dư của giao dịch mới.
+// replaced là giao dịch đang chờ sẽ bị thay thế (nếu có), không tính vào các khoản đang chờ.
+func (bc *Blockchain) checkTransaction(transaction, replaced *pool.Transaction) error {
+	// Giao dịch thưởng và genesis chỉ được chain tạo ra
+	if transaction.IsSystem() {
+		return fmt.Errorf("%s transactions cannot be submitted", transaction.Type)
+	}
+
+	if err := validateTransaction(transaction); err != nil {
+		return err
+	}
+
+	// Block kế tiếp có index len(bc.Chain)
+	if transaction.IsExpired(int64(len(bc.Chain))) {
+		return fmt.Errorf("transaction expired at height %d, next block is %d", transaction.ValidUntilHeight, len(bc.Chain))
+	}
+
+	if err := bc.checkStakingTransaction(transaction, replaced); err != nil {
+		return err
+	}
+
+	// Lấy balance hiện tại của người gửi
+	balance, exists := bc.Balances[transaction.From]
+	if !exists { // Nếu chưa có trong map
+		balance = 0 // Đặt balance = 0
+	}
+	// Kiểm tra đủ tiền cho giao dịch này (amount + fee) cùng các giao dịch đang chờ của người gửi
+	cost, err := transaction.Cost()
+	if err != nil {
+		return err
+	}
+	pending, err := bc.pendingSpend(transaction.From)
+	if err != nil {
+		return err
+	}
+	if replaced != nil {
+		replacedCost, err := replaced.Cost()
+		if err != nil {
+			return err
+		}
+		if pending, err = pending.Sub(replacedCost); err != nil {
+			return err
 		}
 	}
+	required, err := pending.Add(cost)
+	if err != nil {
+		return err
+	}
+	if balance < required {
+		return fmt.Errorf("insufficient balance: balance %s MYC, %s MYC already pending, %s MYC required",
+			balance, pending, cost) // Lỗi: Không đủ tiền
+	}
 
-	return transactions
+	return nil
 }
 
-func (bc *Blockchain) AddTransaction(transaction *pool.Transaction) error {
+// pendingSpend là tổng số coin (amount + fee) address sẽ bị trừ bởi các giao dịch đang chờ
+func (bc *Blockchain) pendingSpend(address string) (coin.Amount, error) {
+	var total coin.Amount
+	for _, tx := range bc.TxPool.GetBySender(address) {
+		cost, err := tx.Cost()
+		if err != nil {
+			return 0, err
+		}
+		if total, err = total.Add(cost); err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+// GetAvailableBalance trả về số dư đã xác nhận trừ đi các khoản đang chờ của address
+func (bc *Blockchain) GetAvailableBalance(address string) coin.Amount {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	return bc.availableBalance(address)
+}
+
+func (bc *Blockchain) availableBalance(address string) coin.Amount {
+	pending, err := bc.pendingSpend(address)
+	if err != nil {
+		return 0
+	}
+	available, err := bc.Balances[address].Sub(pending)
+	if err != nil {
+		return 0
+	}
+	return available
+}
+
+// HasPendingTransfer cho biết pending pool đã có giao dịch chuyển coin từ from đến to
+func (bc *Blockchain) HasPendingTransfer(from, to string) bool {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	for _, tx := range bc.TxPool.GetBySender(from) {
+		if tx.Type == pool.TxTransfer && tx.To == to {
+			return true
+		}
+	}
+	return false
+}
+
+// checkStakingTransaction kiểm tra trước giao dịch stake/unstake với StakingPool hiện tại
+// và các giao dịch đang chờ (trừ giao dịch replaced sắp bị thay thế)
+func (bc *Blockchain) checkStakingTransaction(transaction, replaced *pool.Transaction) error {
+	switch transaction.Type {
+	case pool.TxStake:
+		if transaction.Amount < bc.StakingPool.MinStakeAmount {
+			return fmt.Errorf("minimum stake amount is %s MYC", bc.StakingPool.MinStakeAmount)
+		}
+		if _, err := bc.StakingPool.GetValidatorInfo(transaction.From); err == nil {
+			return fmt.Errorf("validator already exists")
+		}
+	case pool.TxUnstake:
+		if _, err := bc.StakingPool.GetValidatorInfo(transaction.From); err != nil {
+			return err
+		}
+	}
+
+	for _, pending := range bc.TxPool.GetBySender(transaction.From) {
+		if pending == replaced {
+			continue
+		}
+		if pending.Type == transaction.Type &&
+			(pending.Type == pool.TxStake || pending.Type == pool.TxUnstake) {
+			return fmt.Errorf("a %s transaction from %s is already pending", pending.Type, pending.From)
+		}
+	}
+
+	return nil
+}
+
+// GetNextNonce trả về nonce mà giao dịch tiếp theo của address phải dùng,
+// tính cả các giao dịch đang chờ trong pending pool
+func (bc *Blockchain) GetNextNonce(address string) uint64 {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	return bc.nextNonce(address)
+}
+
+// GetConfirmedNonce trả về nonce kế tiếp chỉ tính các giao dịch đã vào block
+func (bc *Blockchain) GetConfirmedNonce(address string) uint64 {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	return bc.Nonces[address]
+}
+
+func (bc *Blockchain) nextNonce(address string) uint64 {
+	nonce := bc.Nonces[address]
+	for _, tx := range bc.TxPool.GetBySender(address) {
+		if tx.Nonce >= nonce {
+			nonce = tx.Nonce + 1
+		}
+	}
+	return nonce
+}
+
+// validateTransaction kiểm tra hash, dữ liệu và chữ ký của một giao dịch
+func validateTransaction(transaction *pool.Transaction) error {
+	if !transaction.IsValid() {
+		return fmt.Errorf("invalid transaction %s", transaction.Hash)
+	}
+
+	if err := transaction.Verify(); err != nil {
+		return fmt.Errorf("transaction %s: %v", transaction.Hash, err)
+	}
+
+	return nil
+}
+
+// validateBlockTransactions kiểm tra mọi giao dịch trong block đều hợp lệ,
+// nonce của mỗi người gửi nối tiếp nonces và block chứa tối đa một giao dịch thưởng
+func validateBlockTransactions(block *Block, nonces map[string]uint64) error {
+	rewardCount := 0
+	expected := make(map[string]uint64)
+	seen := make(map[string]bool, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		if tx.Type == pool.TxGenesis {
+			return fmt.Errorf("block %d contains a genesis transaction", block.Index)
+		}
+
+		// Merkle root không phân biệt giao dịch trùng lặp ở cuối tầng lẻ
+		if seen[tx.Hash] {
+			return fmt.Errorf("block %d contains transaction %s twice", block.Index, tx.Hash)
+		}
+		seen[tx.Hash] = true
+
+		if tx.IsReward() {
+			rewardCount++
+		}
+
+		if err := validateTransaction(tx); err != nil {
+			return err
+		}
+
+		if tx.IsExpired(block.Index) {
+			return fmt.Errorf("transaction %s expired at height %d", tx.Hash, tx.ValidUntilHeight)
+		}
+
+		if tx.IsReward() {
+			continue
+		}
+
+		nonce, ok := expected[tx.From]
+		if !ok {
+			nonce = nonces[tx.From]
+		}
+		if tx.Nonce != nonce {
+			return fmt.Errorf("transaction %s has nonce %d, expected %d", tx.Hash, tx.Nonce, nonce)
+		}
+		expected[tx.From] = nonce + 1
+	}
+
+	if rewardCount > 1 {
+		return fmt.Errorf("block %d contains %d reward transactions", block.Index, rewardCount)
+	}
+
+	return nil
+}
+
+// AddBlock nhập một block đã được validator ký (từ PrepareBlock hoặc từ node khác) sau
+// khi kiểm tra. Block nối tiếp block mới nhất được nối vào chain; block nối vào block khác
+// được lưu vào nhánh phụ và chain reorg sang nhánh đó nếu nhánh nặng hơn (xem fork.go).
+func (bc *Blockchain) AddBlock(block *Block) error {
 	bc.mutex.Lock()
 	defer bc.mutex.Unlock()
 
-	if transaction.From != "genesis" && transaction.From != "" {
-		// Lấy balance hiện tại của người gửi
-		balance, exists := bc.Balances[transaction.From]
-		if !exists { // Nếu chưa có trong map
-			balance = 0.0 // Đặt balance = 0
+	tip := bc.Chain[len(bc.Chain)-1]
+	if err := bc.addBlock(block); err != nil {
+		return err
+	}
+	if bc.Chain[len(bc.Chain)-1] != tip {
+		bc.saveChain()
+	}
+	return nil
+}
+
+// ImportBlocks nhập lần lượt các block như AddBlock, bỏ qua block đã biết, và chỉ lưu
+// blockchain.json một lần cho cả lô thay vì sau mỗi block (dùng khi đồng bộ từ peer).
+// Trả về số block đã xử lý; khi một block bị từ chối, các block trước nó vẫn được giữ.
+func (bc *Blockchain) ImportBlocks(blocks []*Block) (int, error) {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	tip := bc.Chain[len(bc.Chain)-1]
+	imported := 0
+	var err error
+	for _, block := range blocks {
+		if !bc.hasBlock(block.Hash) {
+			if err = bc.addBlock(block); err != nil {
+				break
+			}
+		}
+		imported++
+	}
+	if bc.Chain[len(bc.Chain)-1] != tip {
+		bc.saveChain()
+	}
+	return imported, err
+}
+
+// addBlock kiểm tra và nhập block như AddBlock nhưng không lưu blockchain.json
+func (bc *Blockchain) addBlock(block *Block) error {
+	if bc.hasBlock(block.Hash) {
+		return fmt.Errorf("block %d %s is already known", block.Index, block.Hash)
+	}
+
+	latestBlock := bc.Chain[len(bc.Chain)-1]
+	if block.PreviousHash != latestBlock.Hash {
+		return bc.addForkBlock(block)
+	}
+
+	if block.Index != latestBlock.Index+1 {
+		return fmt.Errorf("unexpected block index %d, expected %d", block.Index, latestBlock.Index+1)
+	}
+
+	if err := bc.validateBlockProducer(block, bc.StakingPool); err != nil {
+		return err
+	}
+
+	if err := validateBlockTime(block, latestBlock, bc.StakingPool); err != nil {
+		return err
+	}
+
+	if err := validateBlockTransactions(block, bc.Nonces); err != nil {
+		return err
+	}
+
+	if err := validateBlockReward(block, bc.StakingPool, bc.isLegacyHeight(block.Index)); err != nil {
+		return fmt.Errorf("block %d: %v", block.Index, err)
+	}
+
+	return bc.commitBlock(block)
+}
+
+// validateBlockProducer kiểm tra hash và chữ ký của block, và validator tạo block
+// đã đăng ký, còn active và đủ stake tối thiểu tại height của block theo stakingPool
+func (bc *Blockchain) validateBlockProducer(block *Block, stakingPool *consensus.StakingPool) error {
+	if block.Version < CurrentBlockVersion && !bc.isLegacyHeight(block.Index) {
+		return fmt.Errorf("block %d: block version %d is not accepted at or above the migration height %d",
+			block.Index, block.Version, bc.legacyHeight)
+	}
+
+	if err := block.VerifyMerkleRoot(); err != nil {
+		return err
+	}
+
+	if block.Hash == "" || block.Hash != block.CalculateHash() {
+		return fmt.Errorf("block %d has an invalid hash", block.Index)
+	}
+
+	if err := block.VerifySignature(); err != nil {
+		return err
+	}
+
+	validator, err := stakingPool.ValidatorAt(block.Validator, block.Index)
+	if err != nil {
+		return fmt.Errorf("block %d: %v", block.Index, err)
+	}
+
+	if !validator.IsActive {
+		return fmt.Errorf("block %d: validator %s is inactive", block.Index, block.Validator)
+	}
+
+	if validator.StakedAmount < stakingPool.MinStakeAmount {
+		return fmt.Errorf("block %d: validator %s has insufficient stake %s MYC",
+			block.Index, block.Validator, validator.StakedAmount)
+	}
+
+	for _, tx := range block.Transactions {
+		if tx.IsReward() && tx.To != block.Validator {
+			return fmt.Errorf("block %d: reward is paid to %s instead of validator %s", block.Index, tx.To, block.Validator)
 		}
-		// Kiểm tra đủ tiền (gồm cả amount + fee)
-		if balance < transaction.Amount+transaction.Fee {
-			return fmt.Errorf("insufficient balance") // Lỗi: Không đủ tiền
+	}
+
+	return nil
+}
+
+// validateBlockTime kiểm tra timestamp của block không nhỏ hơn của block cha parent, không
+// vượt quá đồng hồ của node hơn maxFutureBlockTime và cách block trước của validator (theo
+// stakingPool của state trước block) ít nhất validatorCooldown. Nhờ vậy một validator không
+// thể tạo block nhanh hơn chain thật để dựng trước một nhánh nặng hơn rồi reorg.
+func validateBlockTime(block, parent *Block, stakingPool *consensus.StakingPool) error {
+	if block.Timestamp < parent.Timestamp {
+		return fmt.Errorf("block %d: timestamp %d is before its parent's timestamp %d", block.Index, block.Timestamp, parent.Timestamp)
+	}
+
+	if limit := time.Now().Unix() + maxFutureBlockTime; block.Timestamp > limit {
+		return fmt.Errorf("block %d: timestamp %d is more than %d seconds in the future", block.Index, block.Timestamp, maxFutureBlockTime)
+	}
+
+	if validator, exists := stakingPool.Validators[block.Validator]; exists {
+		if elapsed := block.Timestamp - validator.LastBlockTime; elapsed < validatorCooldown {
+			return fmt.Errorf("block %d: validator %s created its previous block %d seconds earlier, cooldown is %d seconds",
+				block.Index, block.Validator, elapsed, validatorCooldown)
 		}
 	}
 
-	// Thêm transaction vào pending pool
-	bc.PendingTransactions = append(bc.PendingTransactions, transaction)
-	return nil // Thành công
+	return nil
 }
 
-func (bc *Blockchain) MinePendingTransactions(miningRewardAddress string) *Block {
+// MinePendingTransactions tạo block mới từ pending pool và ký bằng ví của validator
+func (bc *Blockchain) MinePendingTransactions(validatorWallet *wallet.Wallet) (*Block, error) {
 	bc.mutex.Lock()
 	defer bc.mutex.Unlock()
 
 	// Log thông tin debug
-	log.Printf("MinePendingTransactions called with address: %s", miningRewardAddress)
+	log.Printf("MinePendingTransactions called with address: %s", validatorWallet.Address)
 	log.Printf("Consensus type: POS")
 
 	// Tạo PoS block với validator được đề xuất
-	block, err := bc.createPoS(miningRewardAddress)
+	block, err := bc.createPoS(validatorWallet)
 	if err != nil {
 		log.Printf("Error creating PoS block: %v", err)
-		return nil
+		return nil, err
 	}
-	return block // Trả về block đã tạo thành công
+	return block, nil // Trả về block đã tạo thành công
 
 }
 
-func (bc *Blockchain) createPoS(proposedValidator string) (*Block, error) {
+// PrepareBlock tạo block chưa ký cho validator. Validator ký Hash ở phía client
+// rồi gửi block đã ký qua AddBlock.
+func (bc *Blockchain) PrepareBlock(validator string) (*Block, error) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	return bc.assembleBlock(validator)
+}
+
+func (bc *Blockchain) createPoS(validatorWallet *wallet.Wallet) (*Block, error) {
 	log.Printf("=== PoS Block Creation Started ===")
+
+	block, err := bc.assembleBlock(validatorWallet.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Signing block #%d...", block.Index)
+	if err := block.Sign(validatorWallet); err != nil {
+		return nil, err
+	}
+
+	if err := bc.commitBlock(block); err != nil {
+		return nil, err
+	}
+	bc.saveChain()
+
+	log.Printf("✓ PoS block creation completed successfully!")
+	log.Printf("=== Block Stats ===")
+	log.Printf("- Block Index: %d", block.Index)
+	log.Printf("- Block Hash: %s", block.Hash)
+	log.Printf("- Transactions: %d", len(block.Transactions))
+	log.Printf("- Validator: %s", block.Validator)
+	log.Printf("- Reward: %s MYC", block.Transactions[len(block.Transactions)-1].Amount)
+	log.Printf("==================")
+
+	return block, nil
+}
+
+// assembleBlock kiểm tra validator và gom các giao dịch pending hợp lệ cùng
+// giao dịch thưởng thành block mới (chưa ký, chưa thêm vào chain)
+func (bc *Blockchain) assembleBlock(proposedValidator string) (*Block, error) {
 	log.Printf("Proposed validator: %s", proposedValidator)
 	log.Printf("Current validators count: %d", len(bc.StakingPool.Validators))
-	log.Printf("Pending transactions: %d", len(bc.PendingTransactions))
+	log.Printf("Pending transactions: %d", bc.TxPool.Len())
 
 	// Strict PoS: Always require valid validator
 	validator, err := bc.StakingPool.GetValidatorInfo(proposedValidator)
@@ -214,136 +837,183 @@ func (bc *Blockchain) createPoS(proposedValidator string) (*Block, error) {
 
 	// Check minimum stake requirement
 	if validator.StakedAmount < bc.StakingPool.MinStakeAmount {
-		log.Printf("ERROR: Insufficient stake: %.2f < %.2f", validator.StakedAmount, bc.StakingPool.MinStakeAmount)
-		return nil, fmt.Errorf("validator %s has insufficient stake: %.2f MYC (minimum: %.2f MYC)",
+		log.Printf("ERROR: Insufficient stake: %s < %s", validator.StakedAmount, bc.StakingPool.MinStakeAmount)
+		return nil, fmt.Errorf("validator %s has insufficient stake: %s MYC (minimum: %s MYC)",
 			proposedValidator, validator.StakedAmount, bc.StakingPool.MinStakeAmount)
 	}
 
 	// Check cooldown period (prevent monopoly)
 	currentTime := time.Now().Unix()
-	if (currentTime - validator.LastBlockTime) < 60 { // 60 seconds cooldown
+	if (currentTime - validator.LastBlockTime) < validatorCooldown {
 		log.Printf("ERROR: Validator in cooldown: %s", proposedValidator)
 		return nil, fmt.Errorf("validator %s must wait %d seconds before creating another block",
-			proposedValidator, 60-(currentTime-validator.LastBlockTime))
+			proposedValidator, validatorCooldown-(currentTime-validator.LastBlockTime))
 	}
 
 	// Validator is valid - proceed with block creation
 	log.Printf("✓ Validator validation passed")
 	selectedValidator := proposedValidator
 
-	// Create reward transaction
-	rewardAmount := bc.StakingPool.BlockReward
-	log.Printf("Creating reward transaction: %s -> %.2f MYC", selectedValidator, rewardAmount)
-	rewardTransaction := pool.NewTransaction("", selectedValidator, rewardAmount, 0)
-	bc.PendingTransactions = append(bc.PendingTransactions, rewardTransaction)
+	// Lấy giao dịch theo phí trên mỗi byte giảm dần (mỗi người gửi vẫn theo thứ tự nonce)
+	// và bỏ qua giao dịch không còn hợp lệ (ví dụ giao dịch chưa ký nạp từ file)
+	pending := bc.TxPool.GetOrderedTransactions()
+
+	// Áp dụng thử từng giao dịch trên bản sao state để block không chứa giao dịch
+	// làm âm số dư hoặc stake/unstake không hợp lệ
+	state := bc.currentState().clone()
+	simulated := &Block{Index: int64(len(bc.Chain)), Timestamp: time.Now().Unix(), Validator: selectedValidator}
+	transactions := make([]*pool.Transaction, 0, len(pending)+1)
+	for _, tx := range pending {
+		if len(transactions) >= maxBlockTransactions {
+			break
+		}
+		if tx.IsSystem() {
+			continue
+		}
+		if err := validateTransaction(tx); err != nil {
+			log.Printf("Dropping pending transaction: %v", err)
+			continue
+		}
+		if tx.IsExpired(simulated.Index) {
+			log.Printf("Dropping pending transaction %s: expired at height %d", tx.Hash, tx.ValidUntilHeight)
+			continue
+		}
+		if tx.Nonce != state.Nonces[tx.From] {
+			log.Printf("Dropping pending transaction %s: nonce %d, expected %d", tx.Hash, tx.Nonce, state.Nonces[tx.From])
+			continue
+		}
+		if err := state.applyTransaction(simulated, tx); err != nil {
+			log.Printf("Dropping pending transaction %s: %v", tx.Hash, err)
+			continue
+		}
+		transactions = append(transactions, tx)
+	}
 
-	log.Printf("Total transactions for block: %d", len(bc.PendingTransactions))
+	return bc.sealBlock(selectedValidator, transactions)
+}
+
+// sealBlock thêm giao dịch thưởng (BlockReward cộng phần phí của validator) vào transactions
+// và tạo block nối tiếp block mới nhất (chưa ký, chưa thêm vào chain)
+func (bc *Blockchain) sealBlock(selectedValidator string, transactions []*pool.Transaction) (*Block, error) {
+	// Create reward transaction: block reward plus the validator's share of the fees
+	fees, err := blockFees(transactions)
+	if err != nil {
+		return nil, err
+	}
+	feeDistribution, err := distributeFees(fees, bc.StakingPool)
+	if err != nil {
+		return nil, err
+	}
+	rewardAmount, err := bc.StakingPool.BlockReward.Add(feeDistribution.Validator)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Creating reward transaction: %s -> %s MYC (fees %s MYC, burned %s MYC, treasury %s MYC)",
+		selectedValidator, rewardAmount, fees, feeDistribution.Burned, feeDistribution.TreasuryAmount)
+	rewardTransaction := pool.NewRewardTransaction(selectedValidator, rewardAmount, feeDistribution)
+	transactions = append(transactions, rewardTransaction)
+
+	log.Printf("Total transactions for block: %d", len(transactions))
 
 	// Get previous block hash
 	previousHash := "0"
+	var previousTime int64
 	if len(bc.Chain) > 0 {
 		latestBlock := bc.Chain[len(bc.Chain)-1]
 		if latestBlock != nil {
 			previousHash = latestBlock.Hash
+			previousTime = latestBlock.Timestamp
 		}
 	}
 
 	// Create new block
 	blockNumber := int64(len(bc.Chain))
 	log.Printf("Creating PoS block #%d with previous hash: %s", blockNumber, previousHash)
-	block := NewBlock(bc.PendingTransactions, previousHash, selectedValidator, blockNumber)
+	block := NewBlock(transactions, previousHash, selectedValidator, blockNumber)
+	// Đồng hồ của node có thể chậm hơn của validator tạo block trước (xem validateBlockTime)
+	if block.Timestamp < previousTime {
+		block.Timestamp = previousTime
+		block.Hash = block.CalculateHash()
+	}
 	log.Printf("✓ PoS block #%d created with hash: %s", blockNumber, block.Hash)
 
-	// Add block to chain
-	log.Printf("Adding block to chain...")
-	bc.Chain = append(bc.Chain, block)
-
-	// Update balances
-	log.Printf("Updating balances...")
-	bc.UpdateBalances(block)
+	return block, nil
+}
 
-	// Reward validator and update their stats
-	log.Printf("Rewarding validator...")
-	err = bc.StakingPool.RewardValidator(selectedValidator, rewardAmount)
+// commitBlock áp dụng block đã được kiểm tra vào state và thêm vào chain.
+// State chỉ được thay khi toàn bộ block áp dụng thành công. Người gọi lưu chain bằng saveChain.
+func (bc *Blockchain) commitBlock(block *Block) error {
+	weight, err := blockWeight(block, bc.StakingPool)
 	if err != nil {
-		log.Printf("WARNING: Failed to reward validator: %v", err)
-		// Continue anyway - block is already created
+		return err
+	}
+	if weight, err = bc.weights[len(bc.weights)-1].Add(weight); err != nil {
+		return err
 	}
 
-	// Clear pending transactions
-	bc.PendingTransactions = []*pool.Transaction{}
-
-	// Save blockchain state
-	log.Printf("Saving blockchain to file...")
-	err = bc.SaveToFile()
-	if err != nil {
-		log.Printf("WARNING: Failed to save blockchain: %v", err)
-		// Continue anyway - block is in memory
+	log.Printf("Updating balances...")
+	state := bc.currentState().clone()
+	if err := state.applyBlock(block); err != nil {
+		log.Printf("ERROR: Failed to update balances: %v", err)
+		return fmt.Errorf("block %d: %v", block.Index, err)
 	}
+	stakeEvents := state.StakingPool.History[len(bc.StakingPool.History):]
+	bc.setState(state)
 
-	log.Printf("✓ PoS block creation completed successfully!")
-	log.Printf("=== Block Stats ===")
-	log.Printf("- Block Index: %d", block.Index)
-	log.Printf("- Block Hash: %s", block.Hash)
-	log.Printf("- Transactions: %d", len(block.Transactions))
-	log.Printf("- Validator: %s", selectedValidator)
-	log.Printf("- Reward: %.2f MYC", rewardAmount)
-	log.Printf("==================")
+	// Add block to chain
+	log.Printf("Adding block to chain...")
+	bc.Chain = append(bc.Chain, block)
+	bc.weights = append(bc.weights, weight)
+	bc.indexBlock(block)
+	bc.updateFinality()
 
-	return block, nil
+	// Remove confirmed transactions from the pending pool
+	bc.removeConfirmedTransactions(block)
+	bc.publishBlock(block, stakeEvents)
+
+	return nil
 }
 
-func (bc *Blockchain) UpdateBalances(block *Block) {
-	for _, tx := range block.Transactions {
-		if tx.From != "" && tx.From != "genesis" {
-			bc.Balances[tx.From] -= (tx.Amount + tx.Fee)
-		}
-		if tx.To != "" {
-			bc.Balances[tx.To] += tx.Amount
-		}
+// saveChain lưu blockchain.json sau khi chain thay đổi. Lỗi chỉ được ghi log vì block đã
+// nằm trong bộ nhớ.
+func (bc *Blockchain) saveChain() {
+	log.Printf("Saving blockchain to file...")
+	if err := bc.SaveToFile(); err != nil {
+		log.Printf("WARNING: Failed to save blockchain: %v", err)
 	}
 }
 
-func (bc *Blockchain) StakeCoins(address string, amount float64) error {
-	bc.mutex.Lock()
-	defer bc.mutex.Unlock()
-
-	// Check if user has enough balance (direct access to avoid mutex deadlock)
-	balance, exists := bc.Balances[address]
-	if !exists {
-		balance = 0.0
-	}
-	if balance < amount {
-		return fmt.Errorf("insufficient balance for staking")
+// removeConfirmedTransactions bỏ khỏi pending pool các giao dịch đã vào block
+// và các giao dịch có nonce đã được dùng
+func (bc *Blockchain) removeConfirmedTransactions(block *Block) {
+	included := make([]string, 0, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		included = append(included, tx.Hash)
 	}
 
-	// Deduct staked amount from balance
-	bc.Balances[address] -= amount
-
-	// Add validator to staking pool
-	err := bc.StakingPool.AddValidator(address, amount)
-	if err != nil {
-		// Refund if failed
-		bc.Balances[address] += amount
-		return err
+	bc.TxPool.RemoveTransactions(included)
+	stale := bc.TxPool.RemoveIf(func(tx *pool.Transaction) bool {
+		return tx.Nonce < bc.Nonces[tx.From]
+	})
+	for _, tx := range stale {
+		bc.recordDropped(tx, models.TxStatusDropped,
+			fmt.Sprintf("nonce %d was used by another confirmed transaction", tx.Nonce))
 	}
-
-	bc.SaveToFile()
-	return nil
 }
 
-func (bc *Blockchain) GetStakingInfo() map[string]interface{} {
+func (bc *Blockchain) GetStakingInfo() *models.StakingInfoResponse {
 	bc.mutex.RLock()
 	defer bc.mutex.RUnlock()
 
-	return map[string]interface{}{
-		"total_staked":      bc.StakingPool.GetTotalStaked(),
-		"min_stake_amount":  bc.StakingPool.MinStakeAmount,
-		"max_validators":    bc.StakingPool.MaxValidators,
-		"active_validators": len(bc.StakingPool.Validators),
-		"block_reward":      bc.StakingPool.BlockReward,
-		"staking_reward":    bc.StakingPool.StakingReward,
-		"slashing_penalty":  bc.StakingPool.SlashingPenalty,
+	return &models.StakingInfoResponse{
+		TotalStaked:      bc.StakingPool.GetTotalStaked(),
+		MinStakeAmount:   bc.StakingPool.MinStakeAmount,
+		MaxValidators:    bc.StakingPool.MaxValidators,
+		ActiveValidators: len(bc.StakingPool.Validators),
+		BlockReward:      bc.StakingPool.BlockReward,
+		StakingReward:    bc.StakingPool.StakingReward,
+		SlashingPenalty:  bc.StakingPool.SlashingPenalty,
+		FeePolicy:        bc.StakingPool.FeePolicy,
 	}
 }
 
@@ -353,26 +1023,3 @@ func (bc *Blockchain) GetValidatorInfo(address string) (*consensus.Validator, er
 
 	return bc.StakingPool.GetValidatorInfo(address)
 }
-
-func (bc *Blockchain) UnstakeCoins(address string) error {
-	bc.mutex.Lock()
-	defer bc.mutex.Unlock()
-
-	validator, err := bc.StakingPool.GetValidatorInfo(address)
-	if err != nil {
-		return err
-	}
-
-	// Return staked coins to balance
-	bc.Balances[address] += validator.StakedAmount
-
-	// Remove validator
-	err = bc.StakingPool.RemoveValidator(address)
-	if err != nil {
-		bc.Balances[address] -= validator.StakedAmount // Revert
-		return err
-	}
-
-	bc.SaveToFile()
-	return nil
-}
@@ -0,0 +1,162 @@
+package blockchain
+
+import (
+	"MyCoinApp/internal/codec"
+	"MyCoinApp/internal/pool"
+	"encoding/hex"
+	"fmt"
+)
+
+// BlockVersion1 là định dạng mã hóa đầu tiên của header block:
+//
+//	version u8 | index i64 | timestamp i64 | previous_hash str | validator str | merkle_root str
+//
+// Bản mã hóa đầy đủ (dùng để lưu trữ) thêm validator_public_key bytes | signature bytes |
+// số giao dịch uvarint | mỗi giao dịch dạng bytes (pool.Transaction.MarshalBinary).
+// Trường mới phải được thêm trong phiên bản mới để hash của block cũ không đổi.
+const BlockVersion1 uint8 = 1
+
+// BlockVersion2 có header giống BlockVersion1 nhưng MerkleRoot được tính bằng cây theo
+// RFC 6962, tách hash của lá và nút trong (xem merkle.go)
+const BlockVersion2 uint8 = 2
+
+// CurrentBlockVersion là phiên bản dùng cho block mới tạo
+const CurrentBlockVersion = BlockVersion2
+
+// EncodeHeader trả về bản mã hóa chuẩn của header block, là dữ liệu được hash và ký
+func (b *Block) EncodeHeader() ([]byte, error) {
+	w := codec.NewWriter()
+	if err := b.writeHeader(w); err != nil {
+		return nil, err
+	}
+	return w.Bytes(), nil
+}
+
+func (b *Block) writeHeader(w *codec.Writer) error {
+	switch b.Version {
+	case BlockVersion1, BlockVersion2:
+		w.WriteUint8(b.Version)
+		w.WriteInt64(b.Index)
+		w.WriteInt64(b.Timestamp)
+		w.WriteString(b.PreviousHash)
+		w.WriteString(b.Validator)
+		w.WriteString(b.MerkleRoot)
+		return nil
+	}
+	return fmt.Errorf("unsupported block version %d", b.Version)
+}
+
+// MarshalBinary mã hóa đầy đủ block gồm header, chữ ký của validator và các giao dịch
+func (b *Block) MarshalBinary() ([]byte, error) {
+	w := codec.NewWriter()
+	if err := b.writeHeader(w); err != nil {
+		return nil, err
+	}
+
+	publicKey, err := hex.DecodeString(b.ValidatorPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("block %d: invalid validator public key encoding", b.Index)
+	}
+	signature, err := hex.DecodeString(b.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("block %d: invalid signature encoding", b.Index)
+	}
+	w.WriteBytes(publicKey)
+	w.WriteBytes(signature)
+
+	w.WriteUvarint(uint64(len(b.Transactions)))
+	for _, tx := range b.Transactions {
+		data, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %v", b.Index, err)
+		}
+		w.WriteBytes(data)
+	}
+
+	return w.Bytes(), nil
+}
+
+// UnmarshalBinary giải mã block từ MarshalBinary và tính lại Hash từ header
+func (b *Block) UnmarshalBinary(data []byte) error {
+	r := codec.NewReader(data)
+	decoded := Block{Version: r.ReadUint8("block version")}
+	if err := r.Err(); err != nil {
+		return err
+	}
+
+	switch decoded.Version {
+	case BlockVersion1, BlockVersion2:
+		decoded.readHeaderV1(r)
+		decoded.ValidatorPublicKey = hex.EncodeToString(r.ReadBytes("block validator public key"))
+		decoded.Signature = hex.EncodeToString(r.ReadBytes("block signature"))
+
+		// Mỗi giao dịch chiếm ít nhất một byte độ dài và MinEncodedSize byte, nên count lớn
+		// hơn số giao dịch phần dữ liệu còn lại chứa được bị từ chối trước khi cấp phát
+		count := r.ReadUvarint("block transaction count")
+		if count > uint64(r.Remaining()/(pool.MinEncodedSize+1)) {
+			return fmt.Errorf("decode block: transaction count %d exceeds remaining data", count)
+		}
+		decoded.Transactions = make([]*pool.Transaction, 0, count)
+		for i := uint64(0); i < count && r.Err() == nil; i++ {
+			tx, err := pool.DecodeTransaction(r.ReadBytes("block transaction"))
+			if r.Err() != nil {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("decode block transaction %d: %v", i, err)
+			}
+			decoded.Transactions = append(decoded.Transactions, tx)
+		}
+	default:
+		return fmt.Errorf("unsupported block version %d", decoded.Version)
+	}
+
+	if err := r.Finish(); err != nil {
+		return err
+	}
+
+	decoded.Hash = decoded.CalculateHash()
+	*b = decoded
+	return nil
+}
+
+func (b *Block) readHeaderV1(r *codec.Reader) {
+	b.Index = r.ReadInt64("block index")
+	b.Timestamp = r.ReadInt64("block timestamp")
+	b.PreviousHash = r.ReadString("block previous hash")
+	b.Validator = r.ReadString("block validator")
+	b.MerkleRoot = r.ReadString("block merkle root")
+}
+
+// DecodeHeader giải mã header từ EncodeHeader và tính Hash. Block trả về không có giao
+// dịch và chữ ký, chỉ dùng để biết vị trí và hash của block trước khi tải đầy đủ.
+func DecodeHeader(data []byte) (*Block, error) {
+	r := codec.NewReader(data)
+	header := Block{Version: r.ReadUint8("block version")}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	switch header.Version {
+	case BlockVersion1, BlockVersion2:
+		header.readHeaderV1(r)
+	default:
+		return nil, fmt.Errorf("unsupported block version %d", header.Version)
+	}
+
+	if err := r.Finish(); err != nil {
+		return nil, err
+	}
+
+	header.Hash = header.CalculateHash()
+	return &header, nil
+}
+
+// DecodeBlock giải mã một block từ bản mã hóa nhị phân đầy đủ
+func DecodeBlock(data []byte) (*Block, error) {
+	var block Block
+	if err := block.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
@@ -0,0 +1,147 @@
+package blockchain
+
+import (
+	"MyCoinApp/internal/coin"
+	"MyCoinApp/internal/pool"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testBlock tạo block cố định của phiên bản version để hash của nó không đổi giữa các lần chạy
+func testBlock(version uint8) *Block {
+	transactions := []*pool.Transaction{
+		pool.NewGenesisTransaction("00f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788", 1000*coin.Unit, 1700000000),
+		pool.NewGenesisTransaction("00a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718", 5*coin.Unit, 1700000000),
+	}
+	block := &Block{
+		Version:            version,
+		Index:              12,
+		Timestamp:          1700000060,
+		Transactions:       transactions,
+		PreviousHash:       strings.Repeat("0f", 32),
+		Validator:          "00f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788",
+		ValidatorPublicKey: strings.Repeat("ab", 64),
+		Signature:          strings.Repeat("cd", 64),
+	}
+	block.MerkleRoot, _ = ComputeMerkleRoot(version, transactions)
+	block.Hash = block.CalculateHash()
+	return block
+}
+
+// Hash của block đã có trên chain không được đổi khi mã hóa thay đổi: các giá trị dưới đây là
+// hash của testBlock ở từng phiên bản
+func TestBlockHashStable(t *testing.T) {
+	tests := []struct {
+		version uint8
+		hash    string
+	}{
+		{BlockVersion1, "d51970730d406718a6486aa27b0f9451342639aba86f7bb7a8432de40f5d9cd9"},
+		{BlockVersion2, "fae7af137cef7a6b4a6c61efee11095dd458a6764d62633120811e9759a33034"},
+	}
+
+	for _, tt := range tests {
+		if hash := testBlock(tt.version).Hash; hash != tt.hash {
+			t.Errorf("version %d: hash = %s, want %s", tt.version, hash, tt.hash)
+		}
+	}
+}
+
+func TestBlockRoundTrip(t *testing.T) {
+	for _, version := range []uint8{BlockVersion1, BlockVersion2} {
+		block := testBlock(version)
+		data, err := block.MarshalBinary()
+		if err != nil {
+			t.Fatalf("version %d: MarshalBinary: %v", version, err)
+		}
+
+		decoded, err := DecodeBlock(data)
+		if err != nil {
+			t.Fatalf("version %d: DecodeBlock: %v", version, err)
+		}
+		if !reflect.DeepEqual(decoded, block) {
+			t.Errorf("version %d: decoded %+v, want %+v", version, decoded, block)
+		}
+		if err := decoded.VerifyMerkleRoot(); err != nil {
+			t.Errorf("version %d: %v", version, err)
+		}
+	}
+}
+
+func TestHeaderRoundTrip(t *testing.T) {
+	for _, version := range []uint8{BlockVersion1, BlockVersion2} {
+		block := testBlock(version)
+		data, err := block.EncodeHeader()
+		if err != nil {
+			t.Fatalf("version %d: EncodeHeader: %v", version, err)
+		}
+
+		header, err := DecodeHeader(data)
+		if err != nil {
+			t.Fatalf("version %d: DecodeHeader: %v", version, err)
+		}
+		if header.Hash != block.Hash || header.Index != block.Index || header.MerkleRoot != block.MerkleRoot {
+			t.Errorf("version %d: decoded header %+v does not match block %+v", version, header, block)
+		}
+	}
+}
+
+func TestDecodeBlockRejectsTruncatedData(t *testing.T) {
+	for _, version := range []uint8{BlockVersion1, BlockVersion2} {
+		data, err := testBlock(version).MarshalBinary()
+		if err != nil {
+			t.Fatalf("version %d: MarshalBinary: %v", version, err)
+		}
+		for n := 0; n < len(data); n++ {
+			if _, err := DecodeBlock(data[:n]); err == nil {
+				t.Errorf("version %d: decoded %d of %d bytes without error", version, n, len(data))
+			}
+		}
+
+		header, err := testBlock(version).EncodeHeader()
+		if err != nil {
+			t.Fatalf("version %d: EncodeHeader: %v", version, err)
+		}
+		for n := 0; n < len(header); n++ {
+			if _, err := DecodeHeader(header[:n]); err == nil {
+				t.Errorf("version %d: decoded %d of %d header bytes without error", version, n, len(header))
+			}
+		}
+	}
+}
+
+func TestDecodeBlockRejectsTrailingBytes(t *testing.T) {
+	for _, version := range []uint8{BlockVersion1, BlockVersion2} {
+		data, err := testBlock(version).MarshalBinary()
+		if err != nil {
+			t.Fatalf("version %d: MarshalBinary: %v", version, err)
+		}
+		if _, err := DecodeBlock(append(data, 0)); err == nil {
+			t.Errorf("version %d: decoded block data with a trailing byte without error", version)
+		}
+
+		header, err := testBlock(version).EncodeHeader()
+		if err != nil {
+			t.Fatalf("version %d: EncodeHeader: %v", version, err)
+		}
+		if _, err := DecodeHeader(append(header, 0)); err == nil {
+			t.Errorf("version %d: decoded header data with a trailing byte without error", version)
+		}
+	}
+}
+
+// Số giao dịch vượt quá dữ liệu còn lại bị từ chối trước khi cấp phát
+func TestDecodeBlockRejectsOversizedTransactionCount(t *testing.T) {
+	block := testBlock(BlockVersion2)
+	block.Transactions = nil
+	data, err := block.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+
+	// Bỏ byte đếm giao dịch (0) và ghi số đếm lớn
+	data = append(data[:len(data)-1], 0xff, 0xff, 0xff, 0xff, 0x0f)
+	if _, err := DecodeBlock(data); err == nil {
+		t.Error("decoded a block with an oversized transaction count without error")
+	}
+}
@@ -0,0 +1,120 @@
+package blockchain
+
+import (
+	"MyCoinApp/internal/consensus"
+	"MyCoinApp/internal/events"
+	"MyCoinApp/internal/models"
+	"MyCoinApp/internal/pool"
+)
+
+// Loại sự kiện của topic transaction
+const (
+	eventTransactionPending  = "pending"
+	eventTransactionReplaced = "replaced"
+	eventTransactionDropped  = "dropped"
+)
+
+// publishTransaction phát sự kiện giao dịch vào pool, bị thay thế hoặc bị loại
+func (bc *Blockchain) publishTransaction(eventType string, tx *pool.Transaction, status, reason string) {
+	bc.events.Publish(events.TopicTransaction, eventType, transactionAddresses(tx), &models.TransactionStatusResponse{
+		Hash:        tx.Hash,
+		Status:      status,
+		Transaction: tx,
+		Reason:      reason,
+	})
+}
+
+// publishBlock phát sự kiện cho block vừa nối vào chain: block mới, thay đổi stake
+// (stakeEvents) và số dư mới của các địa chỉ có giao dịch trong block
+func (bc *Blockchain) publishBlock(block *Block, stakeEvents []*consensus.StakeEvent) {
+	addresses := blockAddresses([]*Block{block})
+
+	bc.events.Publish(events.TopicBlock, "new", addresses, bc.blockResponse(block))
+
+	for _, event := range stakeEvents {
+		bc.events.Publish(events.TopicValidator, string(event.Type), []string{event.Address}, event)
+	}
+
+	for _, address := range addresses {
+		bc.events.Publish(events.TopicBalance, "changed", []string{address}, &models.BalanceResponse{
+			Address:   address,
+			Balance:   bc.Balances[address],
+			Available: bc.availableBalance(address),
+		})
+	}
+}
+
+// publishReorg phát sự kiện reorg, sự kiện block mới cho từng block của nhánh mới (kèm
+// thay đổi stake của block) và số dư mới của các địa chỉ chỉ có giao dịch trong block bị gỡ
+func (bc *Blockchain) publishReorg(reorg *models.ReorgEvent, removed, added []*Block) {
+	addedAddresses := blockAddresses(added)
+	removedAddresses := blockAddresses(removed)
+
+	var addresses []string
+	seen := make(map[string]bool)
+	for _, address := range append(append([]string(nil), removedAddresses...), addedAddresses...) {
+		if !seen[address] {
+			seen[address] = true
+			addresses = append(addresses, address)
+		}
+	}
+	bc.events.Publish(events.TopicBlock, "reorg", addresses, reorg)
+
+	for _, block := range added {
+		var stakeEvents []*consensus.StakeEvent
+		for _, event := range bc.StakingPool.History {
+			if event.Height == block.Index+1 {
+				stakeEvents = append(stakeEvents, event)
+			}
+		}
+		bc.publishBlock(block, stakeEvents)
+	}
+
+	// Số dư của các địa chỉ trong nhánh mới đã được phát cùng block mới
+	covered := make(map[string]bool, len(addedAddresses))
+	for _, address := range addedAddresses {
+		covered[address] = true
+	}
+	for _, address := range removedAddresses {
+		if covered[address] {
+			continue
+		}
+		bc.events.Publish(events.TopicBalance, "changed", []string{address}, &models.BalanceResponse{
+			Address:   address,
+			Balance:   bc.Balances[address],
+			Available: bc.availableBalance(address),
+		})
+	}
+}
+
+// blockAddresses là các địa chỉ (không trùng lặp) có số dư bị ảnh hưởng bởi các block
+func blockAddresses(blocks []*Block) []string {
+	var addresses []string
+	seen := make(map[string]bool)
+	for _, block := range blocks {
+		for _, tx := range block.Transactions {
+			for _, address := range transactionAddresses(tx) {
+				if !seen[address] {
+					seen[address] = true
+					addresses = append(addresses, address)
+				}
+			}
+		}
+	}
+	return addresses
+}
+
+// transactionAddresses là các địa chỉ có số dư bị ảnh hưởng bởi tx
+func transactionAddresses(tx *pool.Transaction) []string {
+	var addresses []string
+	if tx.From != "" {
+		addresses = append(addresses, tx.From)
+	}
+	if tx.To != "" && tx.To != tx.From {
+		addresses = append(addresses, tx.To)
+	}
+	if tx.Fees != nil && !tx.Fees.TreasuryAmount.IsZero() && tx.Fees.Treasury != tx.To {
+		addresses = append(addresses, tx.Fees.Treasury)
+	}
+	return addresses
+}
@@ -0,0 +1,121 @@
+package blockchain
+
+import (
+	"MyCoinApp/internal/models"
+	"MyCoinApp/internal/pool"
+	"fmt"
+)
+
+// Height là số block trong chain (kể cả genesis block)
+func (bc *Blockchain) Height() int64 {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	return int64(len(bc.Chain))
+}
+
+// GetBlocks trả về tối đa limit block bắt đầu từ index from, theo thứ tự index tăng dần
+// hoặc giảm dần (descending). from < 0 nghĩa là bắt đầu từ đầu chain theo thứ tự đã chọn.
+func (bc *Blockchain) GetBlocks(from int64, limit int, descending bool) *models.BlockListResponse {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	height := int64(len(bc.Chain))
+	if from < 0 {
+		from = 0
+		if descending {
+			from = height - 1
+		}
+	}
+
+	step := int64(1)
+	if descending {
+		step = -1
+	}
+
+	blocks := make([]*models.BlockResponse, 0, limit)
+	index := from
+	for ; index >= 0 && index < height && len(blocks) < limit; index += step {
+		blocks = append(blocks, bc.blockResponse(bc.Chain[index]))
+	}
+
+	response := &models.BlockListResponse{
+		Blocks: blocks,
+		Height: height,
+		From:   from,
+		Limit:  limit,
+		Order:  models.OrderAsc,
+	}
+	if descending {
+		response.Order = models.OrderDesc
+	}
+	if index >= 0 && index < height {
+		next := index
+		response.Next = &next
+	}
+	return response
+}
+
+// GetBlockByIndex trả về block có index cho trước
+func (bc *Blockchain) GetBlockByIndex(index int64) (*models.BlockResponse, error) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	if index < 0 || index >= int64(len(bc.Chain)) {
+		return nil, fmt.Errorf("block %d not found", index)
+	}
+	return bc.blockResponse(bc.Chain[index]), nil
+}
+
+// GetBlockByHash trả về block có hash cho trước
+func (bc *Blockchain) GetBlockByHash(hash string) (*models.BlockResponse, error) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	index, exists := bc.blockIndex[hash]
+	if !exists {
+		return nil, fmt.Errorf("block %s not found", hash)
+	}
+	return bc.blockResponse(bc.Chain[index]), nil
+}
+
+// DescribeBlock trả về bản trình bày của block cho API, kể cả block chưa nối vào chain
+// (block mẫu chờ ký có Confirmations = 0)
+func (bc *Blockchain) DescribeBlock(block *Block) *models.BlockResponse {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	return bc.blockResponse(block)
+}
+
+// blockResponse dựng bản trình bày của block cho API; danh sách giao dịch được sao chép
+// để người gọi không giữ slice nội bộ của chain
+func (bc *Blockchain) blockResponse(block *Block) *models.BlockResponse {
+	transactions := make([]*pool.Transaction, len(block.Transactions))
+	copy(transactions, block.Transactions)
+
+	// Block trong chain đã được kiểm tra nên tổng phí không thể tràn
+	fees, _ := blockFees(block.Transactions)
+
+	size := 0
+	if data, err := block.MarshalBinary(); err == nil {
+		size = len(data)
+	}
+
+	return &models.BlockResponse{
+		Version:            block.Version,
+		Index:              block.Index,
+		Timestamp:          block.Timestamp,
+		Hash:               block.Hash,
+		PreviousHash:       block.PreviousHash,
+		MerkleRoot:         block.MerkleRoot,
+		Validator:          block.Validator,
+		ValidatorPublicKey: block.ValidatorPublicKey,
+		Signature:          block.Signature,
+		TransactionCount:   len(transactions),
+		TotalFees:          fees,
+		Size:               size,
+		Confirmations:      int64(len(bc.Chain)) - block.Index,
+		Transactions:       transactions,
+	}
+}
@@ -0,0 +1,97 @@
+package blockchain
+
+import (
+	"MyCoinApp/internal/coin"
+	"MyCoinApp/internal/consensus"
+	"MyCoinApp/internal/models"
+	"MyCoinApp/internal/pool"
+	"fmt"
+)
+
+// ExportTransactionHistory gọi fn cho từng giao dịch đã xác nhận của address theo thứ tự
+// trong chain, kèm số coin address nhận, gửi, phí đã trả và số dư sau giao dịch.
+// Giao dịch và số coin unstake được trả lại lấy trong cùng một lần giữ khóa như
+// EachTransactionWithBlock nên thuộc đúng một chain kể cả khi chain reorg trong lúc ghi.
+func (bc *Blockchain) ExportTransactionHistory(address string, fn func(*models.HistoryRecord) error) error {
+	bc.mutex.RLock()
+	entries := bc.confirmedHistory(address)
+	height := int64(len(bc.Chain))
+	refunds := unstakeRefunds(bc.StakingPool, address)
+	bc.mutex.RUnlock()
+
+	var balance coin.Amount
+	return eachHistoryItem(entries, height, func(item *models.TransactionWithBlock) error {
+		record, err := historyRecord(address, item, refunds)
+		if err != nil {
+			return err
+		}
+
+		if balance, err = balance.Add(record.Received); err != nil {
+			return err
+		}
+		spent, err := record.Sent.Add(record.Fee)
+		if err != nil {
+			return err
+		}
+		if balance, err = balance.Sub(spent); err != nil {
+			return fmt.Errorf("transaction %s: %v", item.Hash, err)
+		}
+		record.Balance = balance
+
+		return fn(record)
+	})
+}
+
+// historyRecord tính số coin address nhận và gửi trong một giao dịch đã xác nhận. refunds là
+// số coin stake được trả lại cho address theo index của block chứa giao dịch unstake.
+func historyRecord(address string, item *models.TransactionWithBlock, refunds map[int64]coin.Amount) (*models.HistoryRecord, error) {
+	tx := item.Transaction
+	record := &models.HistoryRecord{
+		Hash:       tx.Hash,
+		BlockIndex: item.BlockIndex,
+		BlockHash:  item.BlockHash,
+		Timestamp:  item.BlockTimestamp,
+		Type:       tx.Type,
+	}
+
+	if tx.From == address {
+		record.Counterparty = tx.To
+		record.Sent = tx.Amount
+		record.Fee = tx.Fee
+	} else {
+		record.Counterparty = tx.From
+	}
+
+	if tx.To == address {
+		record.Received = tx.Amount
+	}
+	if tx.Fees != nil && tx.Fees.Treasury == address {
+		received, err := record.Received.Add(tx.Fees.TreasuryAmount)
+		if err != nil {
+			return nil, err
+		}
+		record.Received = received
+	}
+
+	if tx.Type == pool.TxUnstake && tx.From == address {
+		refund, exists := refunds[item.BlockIndex]
+		if !exists {
+			return nil, fmt.Errorf("no unstake of %s recorded in block %d", address, item.BlockIndex)
+		}
+		record.Received = refund
+	}
+
+	return record, nil
+}
+
+// unstakeRefunds trả về số coin stake được trả lại cho address theo index của block chứa
+// giao dịch unstake, đọc từ lịch sử của stakingPool
+func unstakeRefunds(stakingPool *consensus.StakingPool, address string) map[int64]coin.Amount {
+	refunds := make(map[int64]coin.Amount)
+	for _, event := range stakingPool.History {
+		if event.Type == consensus.StakeEventUnstake && event.Address == address {
+			refunds[event.Height-1] = event.Amount
+		}
+	}
+	return refunds
+}
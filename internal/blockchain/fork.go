@@ -0,0 +1,378 @@
+package blockchain
+
+import (
+	"MyCoinApp/internal/coin"
+	"MyCoinApp/internal/consensus"
+	"MyCoinApp/internal/models"
+	"MyCoinApp/internal/pool"
+	"fmt"
+	"log"
+)
+
+// Fork choice: node giữ mọi block hợp lệ nhận được thành một cây. Chain chính (Chain) là
+// nhánh có weight lớn nhất, với weight của một block là stake của validator tạo block tại
+// height đó và weight của nhánh là tổng weight từ genesis. Các block còn lại nằm trong
+// sideBlocks. Khi một nhánh phụ nặng hơn chain chính, chain được reorg sang nhánh đó.
+//
+// Block có index là bội của checkpointInterval là checkpoint; checkpoint có ít nhất
+// finalityDepth block phía sau là final: chain không bao giờ reorg qua checkpoint final
+// và các nhánh rẽ ra trước đó bị bỏ.
+const (
+	checkpointInterval = 10
+	finalityDepth      = 20
+)
+
+// maxSideBlocksPerValidator là số block tối đa của một validator trên các nhánh phụ. Giới hạn
+// tính riêng cho từng validator để một validator tạo nhiều nhánh không chiếm chỗ của nhánh phụ
+// do validator khác tạo. Nhánh rẽ ra trước checkpoint final bị bỏ nên validator trung thực ít
+// khi chạm giới hạn này.
+const maxSideBlocksPerValidator = 32
+
+// ErrUnknownParent là lỗi của block không nối vào block nào node biết (node cần đồng bộ)
+var ErrUnknownParent = fmt.Errorf("block does not link to a known block")
+
+// ErrBelowFinality là lỗi của block thuộc nhánh rẽ ra trước checkpoint final
+var ErrBelowFinality = fmt.Errorf("block forks below the finalized checkpoint")
+
+// ErrTooManySideBlocks là lỗi của block nhánh phụ khi validator của block đã có
+// maxSideBlocksPerValidator block nhánh phụ
+var ErrTooManySideBlocks = fmt.Errorf("too many side branch blocks")
+
+// ErrDoubleSigned là lỗi của block nhánh phụ khi validator của block đã ký một block nhánh phụ
+// khác ở cùng height. Validator trung thực chỉ ký thêm một block ở height đã có block của mình
+// khi chain của node validator reorg sang nhánh khác.
+var ErrDoubleSigned = fmt.Errorf("validator already signed a side branch block at this height")
+
+// sideBlock là block hợp lệ không thuộc chain chính. weight là weight tích lũy từ genesis.
+type sideBlock struct {
+	block  *Block
+	weight coin.Amount
+}
+
+// blockWeight là stake của validator tạo block tại height của block theo stakingPool
+// của state trước block
+func blockWeight(block *Block, stakingPool *consensus.StakingPool) (coin.Amount, error) {
+	validator, err := stakingPool.ValidatorAt(block.Validator, block.Index)
+	if err != nil {
+		return 0, fmt.Errorf("block %d: %v", block.Index, err)
+	}
+	return validator.StakedAmount, nil
+}
+
+// reweighChain tính lại weight tích lũy của chain chính từ lịch sử stake của StakingPool
+// hiện tại, rồi tính checkpoint final
+func (bc *Blockchain) reweighChain() error {
+	weights := make([]coin.Amount, len(bc.Chain))
+	for i := 1; i < len(bc.Chain); i++ {
+		weight, err := blockWeight(bc.Chain[i], bc.StakingPool)
+		if err != nil {
+			return err
+		}
+		if weights[i], err = weights[i-1].Add(weight); err != nil {
+			return err
+		}
+	}
+
+	bc.weights = weights
+	bc.finalized = 0
+	bc.updateFinality()
+	return nil
+}
+
+// finalizedCheckpoint trả về checkpoint final khi block mới nhất có index tip
+func finalizedCheckpoint(tip int64) int64 {
+	if tip < finalityDepth {
+		return 0
+	}
+	return (tip - finalityDepth) / checkpointInterval * checkpointInterval
+}
+
+// stateAt dựng lại state sau block index của chain chính. State được dựng từ state đã lưu
+// trong forkStates gần nhất phía dưới index; forkStates luôn có state sau checkpoint final
+// (xem updateFinality) nên chỉ các block sau checkpoint đó được replay, trừ khi checkpoint
+// final còn là genesis. State kết quả và state tại các checkpoint đi qua được lưu lại theo
+// hash của block, để các block rẽ ra gần đó không phải replay lại.
+func (bc *Blockchain) stateAt(index int64) (*chainState, error) {
+	if index == int64(len(bc.Chain))-1 {
+		return bc.currentState().clone(), nil
+	}
+
+	hash := bc.Chain[index].Hash
+	if cached, exists := bc.forkStates[hash]; exists {
+		return cached.clone(), nil
+	}
+
+	state, start := newChainState(bc.feePolicy), int64(0)
+	for i := index - 1; i >= bc.finalized; i-- {
+		if cached, exists := bc.forkStates[bc.Chain[i].Hash]; exists {
+			state, start = cached.clone(), i+1
+			break
+		}
+	}
+	for _, block := range bc.Chain[start : index+1] {
+		if err := state.applyBlock(block); err != nil {
+			return nil, fmt.Errorf("block %d: %v", block.Index, err)
+		}
+		if block.Index%checkpointInterval == 0 && block.Index < index {
+			bc.forkStates[block.Hash] = state.clone()
+		}
+	}
+
+	bc.forkStates[hash] = state.clone()
+	return state, nil
+}
+
+// pruneForkStates bỏ các state trong forkStates của block không còn trên chain chính hoặc
+// nằm trước checkpoint final, vì không block mới nào còn rẽ ra từ đó được
+func (bc *Blockchain) pruneForkStates() {
+	for hash := range bc.forkStates {
+		if index, exists := bc.blockIndex[hash]; !exists || index < bc.finalized {
+			delete(bc.forkStates, hash)
+		}
+	}
+}
+
+// branchOf trả về index của block trên chain chính mà nhánh chứa block hash rẽ ra, cùng
+// các block của nhánh tính từ sau block đó đến hết block hash. Trả về -1 nếu node không
+// biết block hash.
+func (bc *Blockchain) branchOf(hash string) (int64, []*sideBlock) {
+	var branch []*sideBlock
+	for {
+		if index, exists := bc.blockIndex[hash]; exists {
+			for i, j := 0, len(branch)-1; i < j; i, j = i+1, j-1 {
+				branch[i], branch[j] = branch[j], branch[i]
+			}
+			return index, branch
+		}
+		side, exists := bc.sideBlocks[hash]
+		if !exists {
+			return -1, nil
+		}
+		branch = append(branch, side)
+		hash = side.block.PreviousHash
+	}
+}
+
+// checkSideBlockLimit từ chối block nhánh phụ khi validator của block đã ký một block nhánh
+// phụ khác ở cùng height hoặc đã có maxSideBlocksPerValidator block nhánh phụ
+func (bc *Blockchain) checkSideBlockLimit(block *Block) error {
+	count := 0
+	for hash, side := range bc.sideBlocks {
+		if side.block.Validator != block.Validator {
+			continue
+		}
+		if side.block.Index == block.Index {
+			return fmt.Errorf("block %d: validator %s already signed side branch block %s: %w",
+				block.Index, block.Validator, hash, ErrDoubleSigned)
+		}
+		count++
+	}
+	if count >= maxSideBlocksPerValidator {
+		return fmt.Errorf("block %d: validator %s already has %d blocks on side branches: %w",
+			block.Index, block.Validator, count, ErrTooManySideBlocks)
+	}
+	return nil
+}
+
+// addForkBlock kiểm tra block không nối tiếp block mới nhất với state của block cha,
+// lưu block vào nhánh phụ và reorg nếu nhánh của block nặng hơn chain chính
+func (bc *Blockchain) addForkBlock(block *Block) error {
+	ancestor, branch := bc.branchOf(block.PreviousHash)
+	if ancestor < 0 {
+		return fmt.Errorf("block %d: %w", block.Index, ErrUnknownParent)
+	}
+	if ancestor < bc.finalized {
+		return fmt.Errorf("block %d forks at block %d before checkpoint %d: %w",
+			block.Index, ancestor, bc.finalized, ErrBelowFinality)
+	}
+	if expected := ancestor + int64(len(branch)) + 1; block.Index != expected {
+		return fmt.Errorf("unexpected block index %d, expected %d", block.Index, expected)
+	}
+	if err := bc.checkSideBlockLimit(block); err != nil {
+		return err
+	}
+
+	state, err := bc.stateAt(ancestor)
+	if err != nil {
+		return err
+	}
+	parent := bc.Chain[ancestor]
+	parentWeight := bc.weights[ancestor]
+	for _, side := range branch {
+		if err := state.applyBlock(side.block); err != nil {
+			return fmt.Errorf("block %d: %v", side.block.Index, err)
+		}
+		parent = side.block
+		parentWeight = side.weight
+	}
+
+	if err := bc.validateBlockProducer(block, state.StakingPool); err != nil {
+		return err
+	}
+	if err := validateBlockTime(block, parent, state.StakingPool); err != nil {
+		return err
+	}
+	if err := validateBlockTransactions(block, state.Nonces); err != nil {
+		return err
+	}
+	if err := validateBlockReward(block, state.StakingPool, bc.isLegacyHeight(block.Index)); err != nil {
+		return fmt.Errorf("block %d: %v", block.Index, err)
+	}
+
+	weight, err := blockWeight(block, state.StakingPool)
+	if err != nil {
+		return err
+	}
+	if weight, err = parentWeight.Add(weight); err != nil {
+		return err
+	}
+	if err := state.applyBlock(block); err != nil {
+		return fmt.Errorf("block %d: %v", block.Index, err)
+	}
+
+	side := &sideBlock{block: block, weight: weight}
+	bc.sideBlocks[block.Hash] = side
+
+	// Weight bằng nhau thì giữ chain đã có
+	chainWeight := bc.weights[len(bc.weights)-1]
+	if weight <= chainWeight {
+		log.Printf("Block #%d %s stored on a side branch (weight %s MYC, chain weight %s MYC)",
+			block.Index, block.Hash, weight, chainWeight)
+		return nil
+	}
+	return bc.reorganize(ancestor, append(branch, side), state)
+}
+
+// reorganize chuyển chain chính sang branch rẽ ra sau block ancestor. state là state sau
+// block cuối của branch. Các block bị gỡ trở thành nhánh phụ; giao dịch của chúng không có
+// trong branch được trả lại pool nếu nonce chưa bị dùng.
+func (bc *Blockchain) reorganize(ancestor int64, branch []*sideBlock, state *chainState) error {
+	oldTip := bc.Chain[len(bc.Chain)-1]
+	oldWeight := bc.weights[len(bc.weights)-1]
+	removed := append([]*Block(nil), bc.Chain[ancestor+1:]...)
+	for i, block := range removed {
+		bc.sideBlocks[block.Hash] = &sideBlock{block: block, weight: bc.weights[ancestor+1+int64(i)]}
+	}
+
+	chain := append([]*Block(nil), bc.Chain[:ancestor+1]...)
+	weights := append([]coin.Amount(nil), bc.weights[:ancestor+1]...)
+	added := make([]*Block, 0, len(branch))
+	included := make(map[string]bool)
+	for _, side := range branch {
+		chain = append(chain, side.block)
+		weights = append(weights, side.weight)
+		added = append(added, side.block)
+		delete(bc.sideBlocks, side.block.Hash)
+		for _, tx := range side.block.Transactions {
+			included[tx.Hash] = true
+		}
+	}
+
+	bc.Chain = chain
+	bc.weights = weights
+	bc.setState(state)
+	bc.reindexTransactions()
+	bc.pruneForkStates()
+
+	// Trả giao dịch của các block bị gỡ về pool trước khi bỏ các giao dịch đã vào branch
+	var returned []*pool.Transaction
+	for _, block := range removed {
+		for _, tx := range block.Transactions {
+			if tx.IsSystem() || included[tx.Hash] {
+				continue
+			}
+			if tx.Nonce < bc.Nonces[tx.From] {
+				bc.recordDropped(tx, models.TxStatusDropped,
+					fmt.Sprintf("nonce %d was used by another transaction after a chain reorganization", tx.Nonce))
+				continue
+			}
+			evicted, err := bc.TxPool.AddTransaction(tx)
+			if err != nil {
+				bc.recordDropped(tx, models.TxStatusDropped, fmt.Sprintf("not returned to the pool after a chain reorganization: %v", err))
+				continue
+			}
+			if evicted != nil {
+				bc.recordDropped(evicted, models.TxStatusDropped,
+					fmt.Sprintf("evicted from full transaction pool by %s", tx.Hash))
+			}
+			returned = append(returned, tx)
+		}
+	}
+	for _, block := range added {
+		bc.removeConfirmedTransactions(block)
+	}
+
+	newTip := chain[len(chain)-1]
+	log.Printf("Chain reorganized at block #%d: %d blocks removed, %d added, tip %s -> %s (weight %s -> %s MYC)",
+		ancestor, len(removed), len(added), oldTip.Hash, newTip.Hash, oldWeight, weights[len(weights)-1])
+
+	bc.publishReorg(&models.ReorgEvent{
+		OldTip:               oldTip.Hash,
+		NewTip:               newTip.Hash,
+		CommonAncestor:       chain[ancestor].Hash,
+		AncestorIndex:        ancestor,
+		Removed:              blockHashes(removed),
+		Added:                blockHashes(added),
+		OldWeight:            oldWeight,
+		NewWeight:            weights[len(weights)-1],
+		ReturnedTransactions: transactionHashes(returned),
+	}, removed, added)
+	for _, tx := range returned {
+		bc.publishTransaction(eventTransactionPending, tx, models.TxStatusPending, "returned to the pool after a chain reorganization")
+	}
+
+	bc.updateFinality()
+	return nil
+}
+
+// updateFinality dời checkpoint final theo block mới nhất và bỏ các nhánh phụ rẽ ra trước
+// checkpoint đó. Checkpoint final không bao giờ lùi lại. State sau checkpoint final mới được
+// dựng từ state của checkpoint cũ và giữ trong forkStates cho stateAt.
+func (bc *Blockchain) updateFinality() {
+	checkpoint := finalizedCheckpoint(int64(len(bc.Chain)) - 1)
+	if checkpoint <= bc.finalized {
+		return
+	}
+	if _, err := bc.stateAt(checkpoint); err != nil {
+		log.Printf("WARNING: Failed to build the state at checkpoint %d: %v", checkpoint, err)
+	}
+	bc.finalized = checkpoint
+
+	for hash := range bc.sideBlocks {
+		if ancestor, _ := bc.branchOf(hash); ancestor < bc.finalized {
+			delete(bc.sideBlocks, hash)
+		}
+	}
+	bc.pruneForkStates()
+}
+
+// GetForkChoiceInfo trả về weight của chain chính, checkpoint final và số block trên các nhánh phụ
+func (bc *Blockchain) GetForkChoiceInfo() *models.ForkChoiceInfo {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	return &models.ForkChoiceInfo{
+		ChainWeight:        bc.weights[len(bc.weights)-1],
+		FinalizedIndex:     bc.finalized,
+		FinalizedHash:      bc.Chain[bc.finalized].Hash,
+		CheckpointInterval: checkpointInterval,
+		FinalityDepth:      finalityDepth,
+		SideBlocks:         len(bc.sideBlocks),
+	}
+}
+
+func blockHashes(blocks []*Block) []string {
+	hashes := make([]string, len(blocks))
+	for i, block := range blocks {
+		hashes[i] = block.Hash
+	}
+	return hashes
+}
+
+func transactionHashes(transactions []*pool.Transaction) []string {
+	hashes := make([]string, len(transactions))
+	for i, tx := range transactions {
+		hashes[i] = tx.Hash
+	}
+	return hashes
+}
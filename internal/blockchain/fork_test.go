@@ -0,0 +1,313 @@
+package blockchain
+
+import (
+	"MyCoinApp/internal/coin"
+	"MyCoinApp/internal/consensus"
+	"MyCoinApp/internal/events"
+	"MyCoinApp/internal/models"
+	"MyCoinApp/internal/pool"
+	"MyCoinApp/internal/wallet"
+	"errors"
+	"testing"
+	"time"
+)
+
+// forkTestChain là chain trong thư mục tạm với hai validator: faucet (stake của genesis) và
+// heavy (stake lớn hơn, có hiệu lực từ block 3) để nhánh của heavy nặng hơn nhánh của faucet
+type forkTestChain struct {
+	t      *testing.T
+	bc     *Blockchain
+	bus    *events.Bus
+	faucet *wallet.Wallet
+	heavy  *wallet.Wallet
+}
+
+func newForkTestChain(t *testing.T) *forkTestChain {
+	t.Chdir(t.TempDir())
+
+	faucet := wallet.NewWalletFromPassphrase("fork-test-faucet")
+	bus := events.NewBus()
+	bc, err := NewBlockchain(faucet.Address, consensus.DefaultFeePolicy(), pool.NewTransactionPool(1000, 64), bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &forkTestChain{t: t, bc: bc, bus: bus, faucet: faucet, heavy: wallet.NewWallet()}
+
+	// Block đầu tiên cách hiện tại đủ xa để các nhánh trong test không vượt quá đồng hồ của node
+	first := c.block(bc.Chain[0], faucet, time.Now().Unix()-3*3600, c.transfer(faucet, c.heavy.Address, 1000*coin.Unit))
+	c.add(first)
+	stake := pool.NewStakeTransaction(c.heavy.Address, 500*coin.Unit, coin.Unit/100, 0)
+	if err := stake.SignTransaction(c.heavy.PrivateKey); err != nil {
+		t.Fatal(err)
+	}
+	c.add(c.next(first, faucet, stake))
+	return c
+}
+
+// block tạo block ký bởi validator nối sau prev với timestamp cho trước
+func (c *forkTestChain) block(prev *Block, validator *wallet.Wallet, timestamp int64, txs ...*pool.Transaction) *Block {
+	c.t.Helper()
+	fees, err := blockFees(txs)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	distribution, err := distributeFees(fees, c.bc.StakingPool)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	amount, err := c.bc.StakingPool.BlockReward.Add(distribution.Validator)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+
+	reward := pool.NewRewardTransaction(validator.Address, amount, distribution)
+	block := NewBlock(append([]*pool.Transaction{reward}, txs...), prev.Hash, validator.Address, prev.Index+1)
+	block.Timestamp = timestamp
+	block.Hash = block.CalculateHash()
+	if err := block.Sign(validator); err != nil {
+		c.t.Fatal(err)
+	}
+	return block
+}
+
+// next tạo block sau prev đủ thời gian chờ của validator
+func (c *forkTestChain) next(prev *Block, validator *wallet.Wallet, txs ...*pool.Transaction) *Block {
+	return c.block(prev, validator, prev.Timestamp+validatorCooldown, txs...)
+}
+
+func (c *forkTestChain) add(block *Block) {
+	c.t.Helper()
+	if err := c.bc.AddBlock(block); err != nil {
+		c.t.Fatalf("AddBlock(#%d): %v", block.Index, err)
+	}
+}
+
+func (c *forkTestChain) tip() *Block {
+	return c.bc.Chain[len(c.bc.Chain)-1]
+}
+
+// grow nối n block của validator vào block mới nhất
+func (c *forkTestChain) grow(validator *wallet.Wallet, n int) {
+	for i := 0; i < n; i++ {
+		c.add(c.next(c.tip(), validator))
+	}
+}
+
+func (c *forkTestChain) transfer(from *wallet.Wallet, to string, amount coin.Amount) *pool.Transaction {
+	c.t.Helper()
+	tx := pool.NewTransaction(from.Address, to, amount, coin.Unit/100, c.bc.Nonces[from.Address])
+	if err := tx.SignTransaction(from.PrivateKey); err != nil {
+		c.t.Fatal(err)
+	}
+	return tx
+}
+
+// checkState kiểm tra chain hợp lệ và state hiện tại khớp với state replay từ genesis
+func (c *forkTestChain) checkState() {
+	c.t.Helper()
+	state, result := c.bc.replayChain(nil)
+	if !result.Valid {
+		c.t.Fatalf("chain is invalid at block %d: %s", result.InvalidBlock, result.Reason)
+	}
+	if err := state.matches(c.bc.currentState()); err != nil {
+		c.t.Fatalf("state disagrees with a replay of the chain: %v", err)
+	}
+}
+
+func TestReorgToHeavierBranch(t *testing.T) {
+	c := newForkTestChain(t)
+	parent := c.tip()
+	alice := wallet.NewWallet()
+
+	aliceTx := c.transfer(c.faucet, alice.Address, 7*coin.Unit)
+	faucetBlock := c.next(parent, c.faucet, aliceTx)
+	c.add(faucetBlock)
+	if balance := c.bc.GetBalance(alice.Address); balance != 7*coin.Unit {
+		t.Fatalf("alice balance = %s, want 7", balance)
+	}
+
+	// Nhánh nặng bằng chain chính không làm chain reorg
+	equal := c.block(parent, c.faucet, parent.Timestamp+validatorCooldown+1)
+	c.add(equal)
+	if c.tip() != faucetBlock {
+		t.Fatal("chain reorganized to a branch of equal weight")
+	}
+
+	subscription := c.bus.Subscribe(events.Filter{Topics: map[events.Topic]bool{events.TopicBlock: true}}, 16)
+	defer subscription.Close()
+
+	heavyBlock := c.next(parent, c.heavy)
+	c.add(heavyBlock)
+	if c.tip() != heavyBlock {
+		t.Fatal("chain did not reorganize to the heavier branch")
+	}
+	if balance := c.bc.GetBalance(alice.Address); !balance.IsZero() {
+		t.Errorf("alice balance = %s after the reorg, want 0", balance)
+	}
+	if _, pending := c.bc.TxPool.GetTransaction(aliceTx.Hash); !pending {
+		t.Error("transaction of the removed block was not returned to the pool")
+	}
+	if sides := c.bc.GetForkChoiceInfo().SideBlocks; sides != 2 {
+		t.Errorf("side blocks = %d, want 2", sides)
+	}
+
+	var reorg *models.ReorgEvent
+	for len(subscription.Events) > 0 {
+		event := <-subscription.Events
+		if event.Type == "reorg" {
+			reorg, _ = event.Data.(*models.ReorgEvent)
+		}
+	}
+	if reorg == nil || reorg.OldTip != faucetBlock.Hash || reorg.NewTip != heavyBlock.Hash || reorg.AncestorIndex != parent.Index {
+		t.Errorf("reorg event = %+v", reorg)
+	}
+	c.checkState()
+
+	// Nối tiếp nhánh cũ đến khi nặng hơn thì chain reorg trở lại
+	prev := faucetBlock
+	for i := 0; i < 5; i++ {
+		prev = c.next(prev, c.faucet)
+		c.add(prev)
+	}
+	if c.tip() != prev {
+		t.Fatal("chain did not reorganize back to the extended branch")
+	}
+	if balance := c.bc.GetBalance(alice.Address); balance != 7*coin.Unit {
+		t.Errorf("alice balance = %s after reorganizing back, want 7", balance)
+	}
+	c.checkState()
+}
+
+func TestFinalizedCheckpoint(t *testing.T) {
+	c := newForkTestChain(t)
+	c.grow(c.heavy, 31)
+
+	if tip := c.tip().Index; tip != 33 {
+		t.Fatalf("tip = %d, want 33", tip)
+	}
+	info := c.bc.GetForkChoiceInfo()
+	if info.FinalizedIndex != 10 {
+		t.Fatalf("finalized = %d, want 10", info.FinalizedIndex)
+	}
+
+	deep := c.block(c.bc.Chain[5], c.faucet, c.bc.Chain[5].Timestamp+validatorCooldown+1)
+	if err := c.bc.AddBlock(deep); !errors.Is(err, ErrBelowFinality) {
+		t.Errorf("fork below the finalized checkpoint: err = %v, want ErrBelowFinality", err)
+	}
+
+	// Nhánh rẽ ngay tại checkpoint final vẫn được nhận
+	atCheckpoint := c.block(c.bc.Chain[10], c.faucet, c.bc.Chain[10].Timestamp+validatorCooldown+1)
+	c.add(atCheckpoint)
+
+	// Checkpoint final không lùi khi chain reorg: nhánh nặng hơn rẽ ra sau checkpoint
+	prev := c.bc.Chain[30]
+	for i := 0; i < 5; i++ {
+		prev = c.block(prev, c.heavy, prev.Timestamp+validatorCooldown+1)
+		c.add(prev)
+	}
+	if c.tip() != prev {
+		t.Fatal("chain did not reorganize to the heavier branch")
+	}
+	if finalized := c.bc.GetForkChoiceInfo().FinalizedIndex; finalized != 10 {
+		t.Errorf("finalized = %d after the reorg, want 10", finalized)
+	}
+	c.checkState()
+
+	// Nhánh rẽ trước checkpoint final mới bị bỏ
+	c.grow(c.heavy, 10)
+	if finalized := c.bc.GetForkChoiceInfo().FinalizedIndex; finalized != 20 {
+		t.Fatalf("finalized = %d, want 20", finalized)
+	}
+	if _, exists := c.bc.sideBlocks[atCheckpoint.Hash]; exists {
+		t.Error("branch forking before the finalized checkpoint was kept")
+	}
+}
+
+// stateAt dựng state từ state đã lưu của checkpoint final chứ không replay từ genesis, và state
+// đó vẫn được lưu sau khi checkpoint tiến lên hoặc node nạp lại chain
+func TestFinalizedStateIsCached(t *testing.T) {
+	c := newForkTestChain(t)
+	c.grow(c.heavy, 31)
+
+	finalized := c.bc.Chain[10]
+	cached, exists := c.bc.forkStates[finalized.Hash]
+	if !exists {
+		t.Fatal("state at the finalized checkpoint is not cached")
+	}
+
+	// Đánh dấu state đã lưu: state dựng cho block sau checkpoint phải mang dấu này
+	marker := wallet.NewWallet().Address
+	cached.Balances[marker] = 1
+	state, err := c.bc.stateAt(25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Balances[marker] != 1 {
+		t.Error("stateAt replayed the chain instead of starting from the finalized checkpoint")
+	}
+	if _, exists := c.bc.forkStates[c.bc.Chain[20].Hash]; !exists {
+		t.Error("checkpoint passed while replaying was not cached")
+	}
+	for _, state := range c.bc.forkStates {
+		delete(state.Balances, marker)
+	}
+
+	// Checkpoint final tiến lên: state của checkpoint mới được lưu, state cũ bị bỏ
+	c.grow(c.heavy, 10)
+	if _, exists := c.bc.forkStates[c.bc.Chain[20].Hash]; !exists {
+		t.Error("state at the new finalized checkpoint is not cached")
+	}
+	if _, exists := c.bc.forkStates[finalized.Hash]; exists {
+		t.Error("state before the finalized checkpoint was kept")
+	}
+	want, err := c.bc.stateAt(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := NewBlockchain(c.faucet.Address, consensus.DefaultFeePolicy(), pool.NewTransactionPool(1000, 64), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.finalized != 20 {
+		t.Fatalf("reloaded finalized = %d, want 20", reloaded.finalized)
+	}
+	state, exists = reloaded.forkStates[reloaded.Chain[20].Hash]
+	if !exists {
+		t.Fatal("state at the finalized checkpoint is not cached after reloading")
+	}
+	if err := state.matches(want); err != nil {
+		t.Errorf("reloaded checkpoint state: %v", err)
+	}
+}
+
+func TestSideBlockLimits(t *testing.T) {
+	c := newForkTestChain(t)
+	c.grow(c.heavy, 31)
+	checkpoint := c.bc.Chain[c.bc.finalized]
+
+	// Một block nhánh phụ của faucet ở height 11 được nhận, block thứ hai cùng height thì không
+	c.add(c.block(checkpoint, c.faucet, checkpoint.Timestamp+validatorCooldown+1))
+	err := c.bc.AddBlock(c.block(checkpoint, c.faucet, checkpoint.Timestamp+validatorCooldown+2))
+	if !errors.Is(err, ErrDoubleSigned) {
+		t.Fatalf("second side block at the same height: err = %v, want ErrDoubleSigned", err)
+	}
+
+	// Nhánh dài của faucet chạm giới hạn số block nhánh phụ của faucet
+	prev := c.bc.Chain[c.bc.finalized+1]
+	for i := 1; i < maxSideBlocksPerValidator; i++ {
+		prev = c.block(prev, c.faucet, prev.Timestamp+validatorCooldown+3)
+		c.add(prev)
+	}
+	err = c.bc.AddBlock(c.block(prev, c.faucet, prev.Timestamp+validatorCooldown+3))
+	if !errors.Is(err, ErrTooManySideBlocks) {
+		t.Fatalf("side block over the validator limit: err = %v, want ErrTooManySideBlocks", err)
+	}
+
+	// Giới hạn tính riêng cho từng validator: validator khác vẫn tạo được nhánh phụ
+	parent := c.bc.Chain[len(c.bc.Chain)-2]
+	c.add(c.block(parent, c.heavy, parent.Timestamp+validatorCooldown+1))
+	if sides := c.bc.GetForkChoiceInfo().SideBlocks; sides != maxSideBlocksPerValidator+1 {
+		t.Errorf("side blocks = %d, want %d", sides, maxSideBlocksPerValidator+1)
+	}
+}
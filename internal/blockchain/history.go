@@ -0,0 +1,300 @@
+package blockchain
+
+import (
+	"MyCoinApp/internal/models"
+	"MyCoinApp/internal/pool"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// historyEntry là một giao dịch trong lịch sử của địa chỉ; block = nil với giao dịch đang chờ
+type historyEntry struct {
+	tx       *pool.Transaction
+	block    *Block
+	position int
+}
+
+// historyLess sắp xếp lịch sử theo thứ tự tăng dần: giao dịch đã xác nhận theo (block, vị trí),
+// sau đó là giao dịch đang chờ theo (người gửi, nonce)
+func historyLess(a, b historyKey) bool {
+	if a.pending != b.pending {
+		return !a.pending
+	}
+	if a.pending {
+		if a.from != b.from {
+			return a.from < b.from
+		}
+		return a.nonce < b.nonce
+	}
+	if a.block != b.block {
+		return a.block < b.block
+	}
+	return a.position < b.position
+}
+
+// historyKey là vị trí của một mục trong lịch sử, dùng làm cursor phân trang
+type historyKey struct {
+	pending  bool
+	block    int64
+	position int
+	from     string
+	nonce    uint64
+}
+
+func (e *historyEntry) key() historyKey {
+	if e.block == nil {
+		return historyKey{pending: true, from: e.tx.From, nonce: e.tx.Nonce}
+	}
+	return historyKey{block: e.block.Index, position: e.position}
+}
+
+// encodeCursor mã hóa key thành chuỗi không cần người dùng hiểu
+func encodeCursor(key historyKey) string {
+	var raw string
+	if key.pending {
+		raw = fmt.Sprintf("p.%s.%d", key.from, key.nonce)
+	} else {
+		raw = fmt.Sprintf("c.%d.%d", key.block, key.position)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (historyKey, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return historyKey{}, fmt.Errorf("invalid cursor")
+	}
+
+	parts := strings.Split(string(data), ".")
+	if len(parts) != 3 {
+		return historyKey{}, fmt.Errorf("invalid cursor")
+	}
+	switch parts[0] {
+	case "c":
+		block, err1 := strconv.ParseInt(parts[1], 10, 64)
+		position, err2 := strconv.Atoi(parts[2])
+		if err1 != nil || err2 != nil {
+			return historyKey{}, fmt.Errorf("invalid cursor")
+		}
+		return historyKey{block: block, position: position}, nil
+	case "p":
+		nonce, err := strconv.ParseUint(parts[2], 10, 64)
+		if err != nil {
+			return historyKey{}, fmt.Errorf("invalid cursor")
+		}
+		return historyKey{pending: true, from: parts[1], nonce: nonce}, nil
+	default:
+		return historyKey{}, fmt.Errorf("invalid cursor")
+	}
+}
+
+// indexAddresses thêm vị trí của tx vào lịch sử của người gửi, người nhận
+// và treasury nhận phần phí của block (với giao dịch thưởng)
+func (bc *Blockchain) indexAddresses(tx *pool.Transaction, location txLocation) {
+	addresses := []string{tx.From, tx.To}
+	if tx.Fees != nil && !tx.Fees.TreasuryAmount.IsZero() {
+		addresses = append(addresses, tx.Fees.Treasury)
+	}
+
+	for i, address := range addresses {
+		if address == "" || containsString(addresses[:i], address) {
+			continue
+		}
+		bc.addressIndex[address] = append(bc.addressIndex[address], location)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// receives cho biết address nhận coin từ tx (người nhận hoặc treasury)
+func receives(tx *pool.Transaction, address string) bool {
+	return tx.To == address || (tx.Fees != nil && tx.Fees.Treasury == address && !tx.Fees.TreasuryAmount.IsZero())
+}
+
+// EachTransactionWithBlock gọi fn cho từng giao dịch đã xác nhận của address theo thứ tự
+// trong chain và dừng ở lỗi đầu tiên của fn. Các block và height của chain được lấy trong
+// một lần giữ khóa nên kết quả thuộc đúng một chain kể cả khi chain reorg trong lúc duyệt;
+// khóa không được giữ trong lúc gọi fn để fn có thể ghi chậm (ví dụ ra HTTP).
+func (bc *Blockchain) EachTransactionWithBlock(address string, fn func(*models.TransactionWithBlock) error) error {
+	bc.mutex.RLock()
+	entries := bc.confirmedHistory(address)
+	height := int64(len(bc.Chain))
+	bc.mutex.RUnlock()
+
+	return eachHistoryItem(entries, height, fn)
+}
+
+// eachHistoryItem gọi fn cho từng giao dịch đã xác nhận trong entries, với height là số block
+// của chain lúc lấy entries
+func eachHistoryItem(entries []historyEntry, height int64, fn func(*models.TransactionWithBlock) error) error {
+	for i := range entries {
+		if err := fn(historyItem(&entries[i], height)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// confirmedHistory trả về các giao dịch đã xác nhận của address theo thứ tự trong chain
+func (bc *Blockchain) confirmedHistory(address string) []historyEntry {
+	locations := bc.addressIndex[address]
+	entries := make([]historyEntry, 0, len(locations))
+	for _, location := range locations {
+		block := bc.Chain[location.BlockIndex]
+		entries = append(entries, historyEntry{
+			tx:       block.Transactions[location.Position],
+			block:    block,
+			position: location.Position,
+		})
+	}
+	return entries
+}
+
+// pendingHistory trả về các giao dịch đang chờ gửi từ hoặc đến address
+func (bc *Blockchain) pendingHistory(address string) []historyEntry {
+	var entries []historyEntry
+	for _, tx := range bc.TxPool.GetTransactions() {
+		if tx.From == address || receives(tx, address) {
+			entries = append(entries, historyEntry{tx: tx})
+		}
+	}
+	return entries
+}
+
+// matches cho biết entry thỏa các bộ lọc của query
+func (e *historyEntry) matches(address string, query *models.TransactionHistoryQuery) bool {
+	switch query.Direction {
+	case models.DirectionIn:
+		if !receives(e.tx, address) {
+			return false
+		}
+	case models.DirectionOut:
+		if e.tx.From != address {
+			return false
+		}
+	}
+
+	if query.MinAmount != nil && e.tx.Amount < *query.MinAmount {
+		return false
+	}
+	if query.MaxAmount != nil && e.tx.Amount > *query.MaxAmount {
+		return false
+	}
+
+	// Giao dịch đang chờ chưa có block nên bị loại khi lọc theo block
+	if query.FromBlock != nil || query.ToBlock != nil {
+		if e.block == nil {
+			return false
+		}
+		if query.FromBlock != nil && e.block.Index < *query.FromBlock {
+			return false
+		}
+		if query.ToBlock != nil && e.block.Index > *query.ToBlock {
+			return false
+		}
+	}
+
+	timestamp := e.tx.Timestamp
+	if e.block != nil {
+		timestamp = e.block.Timestamp
+	}
+	if query.FromTime != 0 && timestamp < query.FromTime {
+		return false
+	}
+	if query.ToTime != 0 && timestamp > query.ToTime {
+		return false
+	}
+
+	return true
+}
+
+// historyItem chuyển e thành mục lịch sử; height là số block của chain, dùng để tính số xác nhận
+func historyItem(e *historyEntry, height int64) *models.TransactionWithBlock {
+	if e.block == nil {
+		return &models.TransactionWithBlock{
+			Transaction: e.tx,
+			BlockIndex:  -1,
+			Status:      models.TxStatusPending,
+		}
+	}
+	return &models.TransactionWithBlock{
+		Transaction:    e.tx,
+		BlockIndex:     e.block.Index,
+		BlockHash:      e.block.Hash,
+		BlockTimestamp: e.block.Timestamp,
+		Status:         models.TxStatusConfirmed,
+		Confirmations:  height - e.block.Index,
+	}
+}
+
+// QueryTransactionHistory trả về một trang lịch sử giao dịch của address theo bộ lọc
+// và thứ tự của query. NextCursor của kết quả dùng làm Cursor để lấy trang tiếp theo.
+func (bc *Blockchain) QueryTransactionHistory(address string, query *models.TransactionHistoryQuery) (*models.TransactionHistoryResponse, error) {
+	var after *historyKey
+	if query.Cursor != "" {
+		key, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = &key
+	}
+	descending := query.Order == models.OrderDesc
+
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	entries := bc.confirmedHistory(address)
+	if query.IncludePending {
+		pending := bc.pendingHistory(address)
+		sort.Slice(pending, func(i, j int) bool {
+			return historyLess(pending[i].key(), pending[j].key())
+		})
+		entries = append(entries, pending...)
+	}
+
+	transactions := make([]*models.TransactionWithBlock, 0, query.Limit)
+	var last historyKey
+	var nextCursor string
+	for i := range entries {
+		entry := &entries[i]
+		if descending {
+			entry = &entries[len(entries)-1-i]
+		}
+
+		// Bỏ qua các mục đã trả về ở trang trước
+		if after != nil {
+			key := entry.key()
+			if (!descending && !historyLess(*after, key)) || (descending && !historyLess(key, *after)) {
+				continue
+			}
+		}
+
+		if !entry.matches(address, query) {
+			continue
+		}
+
+		// Còn mục thỏa bộ lọc sau trang này: trang tiếp theo bắt đầu sau mục cuối đã trả về
+		if len(transactions) == query.Limit {
+			nextCursor = encodeCursor(last)
+			break
+		}
+		transactions = append(transactions, historyItem(entry, int64(len(bc.Chain))))
+		last = entry.key()
+	}
+
+	return &models.TransactionHistoryResponse{
+		Address:      address,
+		Transactions: transactions,
+		NextCursor:   nextCursor,
+	}, nil
+}
@@ -0,0 +1,172 @@
+package blockchain
+
+import (
+	"MyCoinApp/internal/models"
+	"MyCoinApp/internal/pool"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Merkle tree của block BlockVersion1: lá là hash của giao dịch, nút cha là
+// sha256(trái || phải) và nút cuối của tầng lẻ được ghép với chính nó. Lá và nút trong
+// dùng chung một cách hash và nút cuối bị nhân đôi nên proof của cây này có thể bị giả
+// (second preimage, lá trùng lặp); cây chỉ còn dùng để kiểm tra block cũ.
+//
+// Từ BlockVersion2, cây theo RFC 6962: lá là sha256(0x00 || hash giao dịch), nút trong là
+// sha256(0x01 || trái || phải) và nút cuối của tầng lẻ được đưa thẳng lên tầng trên.
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+// emptyMerkleRoot là Merkle root của block BlockVersion1 không có giao dịch
+var emptyMerkleRoot = hex.EncodeToString(make([]byte, sha256.Size))
+
+// ComputeMerkleRoot tính Merkle root từ hash của các giao dịch theo cây của phiên bản block version
+func ComputeMerkleRoot(version uint8, transactions []*pool.Transaction) (string, error) {
+	if len(transactions) == 0 {
+		if version == BlockVersion1 {
+			return emptyMerkleRoot, nil
+		}
+		empty := sha256.Sum256(nil)
+		return hex.EncodeToString(empty[:]), nil
+	}
+
+	level, err := merkleLeaves(version, transactions)
+	if err != nil {
+		return "", err
+	}
+	for len(level) > 1 {
+		level = nextMerkleLevel(version, level)
+	}
+	return hex.EncodeToString(level[0]), nil
+}
+
+// BuildMerkleProof tạo các bước chứng minh cho giao dịch thứ index của transactions
+// trong block phiên bản version
+func BuildMerkleProof(version uint8, transactions []*pool.Transaction, index int) ([]*models.MerkleProofStep, error) {
+	if index < 0 || index >= len(transactions) {
+		return nil, fmt.Errorf("transaction index %d out of range", index)
+	}
+
+	level, err := merkleLeaves(version, transactions)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := make([]*models.MerkleProofStep, 0)
+	for len(level) > 1 {
+		if index%2 == 0 {
+			sibling := index + 1
+			if sibling < len(level) {
+				proof = append(proof, &models.MerkleProofStep{Hash: hex.EncodeToString(level[sibling]), Position: "right"})
+			} else if version == BlockVersion1 {
+				proof = append(proof, &models.MerkleProofStep{Hash: hex.EncodeToString(level[index]), Position: "right"})
+			}
+		} else {
+			proof = append(proof, &models.MerkleProofStep{Hash: hex.EncodeToString(level[index-1]), Position: "left"})
+		}
+		level = nextMerkleLevel(version, level)
+		index /= 2
+	}
+	return proof, nil
+}
+
+// VerifyMerkleProof kiểm tra txHash cùng các bước proof cho ra đúng merkleRoot theo cây của
+// phiên bản block version. Proof chỉ có ý nghĩa khi merkleRoot lấy từ header block mà người
+// kiểm tra đã tin cậy, không phải từ chính proof.
+func VerifyMerkleProof(version uint8, txHash string, merkleRoot string, proof []*models.MerkleProofStep) bool {
+	current, err := hex.DecodeString(txHash)
+	if err != nil || len(current) != sha256.Size {
+		return false
+	}
+	if version != BlockVersion1 {
+		current = hashMerkleLeaf(current)
+	}
+
+	for _, step := range proof {
+		sibling, err := hex.DecodeString(step.Hash)
+		if err != nil || len(sibling) != sha256.Size {
+			return false
+		}
+		switch step.Position {
+		case "left":
+			current = hashMerklePair(version, sibling, current)
+		case "right":
+			current = hashMerklePair(version, current, sibling)
+		default:
+			return false
+		}
+	}
+
+	return hex.EncodeToString(current) == merkleRoot
+}
+
+// GetMerkleProof tìm giao dịch đã xác nhận có hash txHash và trả về Merkle proof của nó
+func (bc *Blockchain) GetMerkleProof(txHash string) (*models.MerkleProof, error) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	block, position, found := bc.findTransaction(txHash)
+	if !found {
+		return nil, fmt.Errorf("transaction %s not found in any block", txHash)
+	}
+
+	proof, err := BuildMerkleProof(block.Version, block.Transactions, position)
+	if err != nil {
+		return nil, err
+	}
+	return &models.MerkleProof{
+		BlockVersion: block.Version,
+		TxHash:       txHash,
+		BlockIndex:   block.Index,
+		BlockHash:    block.Hash,
+		MerkleRoot:   block.MerkleRoot,
+		Proof:        proof,
+	}, nil
+}
+
+func merkleLeaves(version uint8, transactions []*pool.Transaction) ([][]byte, error) {
+	leaves := make([][]byte, len(transactions))
+	for i, tx := range transactions {
+		leaf, err := hex.DecodeString(tx.Hash)
+		if err != nil || len(leaf) != sha256.Size {
+			return nil, fmt.Errorf("transaction %d has an invalid hash %q", i, tx.Hash)
+		}
+		if version != BlockVersion1 {
+			leaf = hashMerkleLeaf(leaf)
+		}
+		leaves[i] = leaf
+	}
+	return leaves, nil
+}
+
+func nextMerkleLevel(version uint8, level [][]byte) [][]byte {
+	next := make([][]byte, 0, (len(level)+1)/2)
+	for i := 0; i < len(level); i += 2 {
+		if i+1 < len(level) {
+			next = append(next, hashMerklePair(version, level[i], level[i+1]))
+		} else if version == BlockVersion1 {
+			next = append(next, hashMerklePair(version, level[i], level[i]))
+		} else {
+			next = append(next, level[i])
+		}
+	}
+	return next
+}
+
+func hashMerkleLeaf(txHash []byte) []byte {
+	hash := sha256.Sum256(append([]byte{merkleLeafPrefix}, txHash...))
+	return hash[:]
+}
+
+func hashMerklePair(version uint8, left, right []byte) []byte {
+	data := make([]byte, 0, 1+len(left)+len(right))
+	if version != BlockVersion1 {
+		data = append(data, merkleNodePrefix)
+	}
+	data = append(append(data, left...), right...)
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
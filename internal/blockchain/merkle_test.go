@@ -0,0 +1,195 @@
+package blockchain
+
+import (
+	"MyCoinApp/internal/models"
+	"MyCoinApp/internal/pool"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+// merkleTransactions tạo n giao dịch chỉ có Hash, đủ để dựng cây Merkle
+func merkleTransactions(n int) []*pool.Transaction {
+	transactions := make([]*pool.Transaction, n)
+	for i := range transactions {
+		hash := sha256.Sum256([]byte(fmt.Sprintf("tx-%d", i)))
+		transactions[i] = &pool.Transaction{Hash: hex.EncodeToString(hash[:])}
+	}
+	return transactions
+}
+
+func sha256Hex(parts ...[]byte) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write(part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// Root của cây ba lá được tính tay theo mô tả trong merkle.go
+func TestComputeMerkleRootThreeLeaves(t *testing.T) {
+	transactions := merkleTransactions(3)
+	a := mustDecodeHex(t, transactions[0].Hash)
+	b := mustDecodeHex(t, transactions[1].Hash)
+	c := mustDecodeHex(t, transactions[2].Hash)
+
+	ab := mustDecodeHex(t, sha256Hex(a, b))
+	cc := mustDecodeHex(t, sha256Hex(c, c))
+	wantV1 := sha256Hex(ab, cc)
+
+	leaf := func(h []byte) []byte { return mustDecodeHex(t, sha256Hex([]byte{0x00}, h)) }
+	abV2 := mustDecodeHex(t, sha256Hex([]byte{0x01}, leaf(a), leaf(b)))
+	wantV2 := sha256Hex([]byte{0x01}, abV2, leaf(c))
+
+	for _, tt := range []struct {
+		version uint8
+		want    string
+	}{
+		{BlockVersion1, wantV1},
+		{BlockVersion2, wantV2},
+	} {
+		root, err := ComputeMerkleRoot(tt.version, transactions)
+		if err != nil {
+			t.Fatalf("version %d: %v", tt.version, err)
+		}
+		if root != tt.want {
+			t.Errorf("version %d: root = %s, want %s", tt.version, root, tt.want)
+		}
+	}
+}
+
+func TestComputeMerkleRootEmpty(t *testing.T) {
+	if root, _ := ComputeMerkleRoot(BlockVersion1, nil); root != emptyMerkleRoot {
+		t.Errorf("version 1: empty root = %s, want %s", root, emptyMerkleRoot)
+	}
+	if root, _ := ComputeMerkleRoot(BlockVersion2, nil); root != sha256Hex() {
+		t.Errorf("version 2: empty root = %s, want %s", root, sha256Hex())
+	}
+}
+
+// Proof của mọi giao dịch phải khớp root, với cả số lá lẻ ở các tầng
+func TestMerkleProofAllLeaves(t *testing.T) {
+	for _, version := range []uint8{BlockVersion1, BlockVersion2} {
+		for n := 1; n <= 9; n++ {
+			transactions := merkleTransactions(n)
+			root, err := ComputeMerkleRoot(version, transactions)
+			if err != nil {
+				t.Fatalf("version %d, %d leaves: %v", version, n, err)
+			}
+
+			for i, tx := range transactions {
+				proof, err := BuildMerkleProof(version, transactions, i)
+				if err != nil {
+					t.Fatalf("version %d, %d leaves, leaf %d: %v", version, n, i, err)
+				}
+				if !VerifyMerkleProof(version, tx.Hash, root, proof) {
+					t.Errorf("version %d, %d leaves: proof of leaf %d does not verify", version, n, i)
+				}
+
+				other := transactions[(i+1)%n].Hash
+				if n > 1 && VerifyMerkleProof(version, other, root, proof) {
+					t.Errorf("version %d, %d leaves: proof of leaf %d verifies another transaction", version, n, i)
+				}
+			}
+		}
+	}
+}
+
+func TestVerifyMerkleProofRejectsTamperedProof(t *testing.T) {
+	for _, version := range []uint8{BlockVersion1, BlockVersion2} {
+		transactions := merkleTransactions(5)
+		root, _ := ComputeMerkleRoot(version, transactions)
+		proof, err := BuildMerkleProof(version, transactions, 2)
+		if err != nil {
+			t.Fatalf("version %d: %v", version, err)
+		}
+
+		flipped := *proof[0]
+		if flipped.Position == "left" {
+			flipped.Position = "right"
+		} else {
+			flipped.Position = "left"
+		}
+		wrongPosition := append([]*models.MerkleProofStep{&flipped}, proof[1:]...)
+		if VerifyMerkleProof(version, transactions[2].Hash, root, wrongPosition) {
+			t.Errorf("version %d: proof with a swapped position verifies", version)
+		}
+
+		if VerifyMerkleProof(version, transactions[2].Hash, root, proof[:len(proof)-1]) {
+			t.Errorf("version %d: truncated proof verifies", version)
+		}
+
+		invalid := *proof[0]
+		invalid.Position = "middle"
+		if VerifyMerkleProof(version, transactions[2].Hash, root, append([]*models.MerkleProofStep{&invalid}, proof[1:]...)) {
+			t.Errorf("version %d: proof with an unknown position verifies", version)
+		}
+	}
+}
+
+// Proof của cây cũ không dùng được với root của cây RFC 6962 và ngược lại
+func TestVerifyMerkleProofChecksVersion(t *testing.T) {
+	transactions := merkleTransactions(4)
+	rootV1, _ := ComputeMerkleRoot(BlockVersion1, transactions)
+	proofV1, _ := BuildMerkleProof(BlockVersion1, transactions, 1)
+	if VerifyMerkleProof(BlockVersion2, transactions[1].Hash, rootV1, proofV1) {
+		t.Error("version 1 proof verifies as version 2")
+	}
+
+	rootV2, _ := ComputeMerkleRoot(BlockVersion2, transactions)
+	proofV2, _ := BuildMerkleProof(BlockVersion2, transactions, 1)
+	if VerifyMerkleProof(BlockVersion1, transactions[1].Hash, rootV2, proofV2) {
+		t.Error("version 2 proof verifies as version 1")
+	}
+}
+
+// Cây cũ nhân đôi lá cuối nên danh sách có lá cuối bị lặp lại cho cùng root; cây RFC 6962 thì không
+func TestMerkleRootDuplicateLastLeaf(t *testing.T) {
+	transactions := merkleTransactions(3)
+	duplicated := append(append([]*pool.Transaction(nil), transactions...), transactions[2])
+
+	rootV1, _ := ComputeMerkleRoot(BlockVersion1, transactions)
+	duplicatedV1, _ := ComputeMerkleRoot(BlockVersion1, duplicated)
+	if rootV1 != duplicatedV1 {
+		t.Error("version 1 roots differ, the duplicated leaf weakness is expected")
+	}
+
+	rootV2, _ := ComputeMerkleRoot(BlockVersion2, transactions)
+	duplicatedV2, _ := ComputeMerkleRoot(BlockVersion2, duplicated)
+	if rootV2 == duplicatedV2 {
+		t.Error("version 2 root does not change when the last leaf is duplicated")
+	}
+}
+
+// Nút trong không được dùng làm lá: hash của nút trong của cây RFC 6962 không phải là lá hợp lệ
+func TestMerkleProofRejectsInnerNodeAsLeaf(t *testing.T) {
+	transactions := merkleTransactions(4)
+	root, _ := ComputeMerkleRoot(BlockVersion2, transactions)
+	proof, _ := BuildMerkleProof(BlockVersion2, transactions, 0)
+
+	// Nút trong chứa lá 0 và 1, kèm phần còn lại của proof
+	leaves, _ := merkleLeaves(BlockVersion2, transactions)
+	inner := hex.EncodeToString(hashMerklePair(BlockVersion2, leaves[0], leaves[1]))
+	if VerifyMerkleProof(BlockVersion2, inner, root, proof[1:]) {
+		t.Error("an inner node verifies as a transaction")
+	}
+}
+
+func TestBuildMerkleProofIndexOutOfRange(t *testing.T) {
+	transactions := merkleTransactions(3)
+	for _, index := range []int{-1, 3} {
+		if _, err := BuildMerkleProof(BlockVersion2, transactions, index); err == nil {
+			t.Errorf("index %d: expected an error", index)
+		}
+	}
+}
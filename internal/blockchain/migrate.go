@@ -0,0 +1,232 @@
+package blockchain
+
+import (
+	"MyCoinApp/internal/coin"
+	"MyCoinApp/internal/pool"
+	"MyCoinApp/internal/wallet"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+)
+
+// snapshotVersion là phiên bản định dạng hiện tại của blockchain.json.
+//
+//	0: số lượng coin lưu dạng float64
+//	1: số lượng coin lưu dạng coin.Amount (fixed-point 8 chữ số thập phân)
+//	2: coin ban đầu, faucet và stake nằm trong block; state được dựng lại từ chain
+//	3: hash của block tính từ Merkle root của các giao dịch
+//	4: block và giao dịch lưu và hash bằng mã hóa nhị phân chuẩn có phiên bản
+//	5: phí giao dịch được chia cho validator, burn và treasury trong giao dịch thưởng
+const snapshotVersion = 5
+
+// replayableSnapshotVersion là phiên bản cũ nhất có chain replay được với luật hiện tại.
+// Trước phiên bản 2, faucet và stake thay đổi số dư ngoài block và giao dịch không có chữ ký;
+// ở phiên bản 3 và 4 cách tính hash thay đổi. Block của phiên bản 4 nằm dưới migration point
+// (xem legacyHeightOf) nên được kiểm tra với luật thưởng cũ (xem validateBlockReward). File cũ
+// hơn được chuyển sang chain mới bằng migrateLegacySnapshot.
+const replayableSnapshotVersion = 4
+
+// legacyHeightOf trả về migration point của chain nạp từ blockchain.json: ngay sau block
+// BlockVersion1 cuối cùng (không tính genesis, luôn là BlockVersion1). Block mới từ height
+// này trở đi phải dùng CurrentBlockVersion và luật thưởng hiện tại.
+func legacyHeightOf(chain []*Block) int64 {
+	for i := len(chain) - 1; i > 0; i-- {
+		if chain[i].Version == BlockVersion1 {
+			return chain[i].Index + 1
+		}
+	}
+	return 0
+}
+
+// isLegacyHeight cho biết block ở index được tạo trước migration point của chain
+func (bc *Blockchain) isLegacyHeight(index int64) bool {
+	return index < bc.legacyHeight
+}
+
+// checkSnapshotVersion từ chối blockchain.json do node mới hơn tạo
+func checkSnapshotVersion(version int) error {
+	if version > snapshotVersion {
+		return fmt.Errorf("blockchain.json version %d is newer than this node supports (%d)", version, snapshotVersion)
+	}
+	return nil
+}
+
+// legacySnapshot là phần của blockchain.json phiên bản 0 đến 3 cần để chuyển số dư sang
+// chain mới. Số lượng coin được đọc dạng float64 vì phiên bản 0 lưu float64 và các phiên
+// bản sau lưu coin.Amount dưới dạng số JSON.
+type legacySnapshot struct {
+	Chain []struct {
+		Transactions []struct {
+			Type pool.TransactionType `json:"type"`
+			To   string               `json:"to"`
+		} `json:"transactions"`
+	} `json:"chain"`
+	Balances    map[string]float64 `json:"balances"`
+	StakingPool *struct {
+		Validators map[string]*struct {
+			StakedAmount float64 `json:"staked_amount"`
+		} `json:"validators"`
+	} `json:"staking_pool"`
+}
+
+// legacyGenesisAccount là tài khoản giả giữ coin ban đầu ở blockchain.json phiên bản 0
+const legacyGenesisAccount = "genesis"
+
+// credits trả về số coin cần chuyển lại cho mỗi địa chỉ: số dư cộng stake (stake được trả
+// lại thành số dư). Tài khoản nhận coin ban đầu của chain cũ không được chuyển vì coin của
+// nó đã nằm ở ví faucet của chain mới.
+func (legacy *legacySnapshot) credits() (map[string]coin.Amount, error) {
+	skip := map[string]bool{legacyGenesisAccount: true}
+	for _, block := range legacy.Chain {
+		for _, tx := range block.Transactions {
+			if tx.Type == pool.TxGenesis {
+				skip[tx.To] = true
+			}
+		}
+	}
+
+	amounts := make(map[string]float64, len(legacy.Balances))
+	for address, balance := range legacy.Balances {
+		// Số dư âm do lỗi double-spend ở phiên bản 0
+		if balance > 0 {
+			amounts[address] += balance
+		}
+	}
+	if legacy.StakingPool != nil {
+		for address, validator := range legacy.StakingPool.Validators {
+			if validator != nil && validator.StakedAmount > 0 {
+				amounts[address] += validator.StakedAmount
+			}
+		}
+	}
+
+	credits := make(map[string]coin.Amount, len(amounts))
+	for address, value := range amounts {
+		if skip[address] {
+			continue
+		}
+		amount, err := coin.FromFloat(value)
+		if err != nil {
+			return nil, fmt.Errorf("balance of %s: %v", address, err)
+		}
+		if !amount.IsZero() {
+			credits[address] = amount
+		}
+	}
+	return credits, nil
+}
+
+// migrateLegacySnapshot chuyển blockchain.json có chain không replay được sang chain mới bắt
+// đầu từ genesis block của node. Số dư và stake của mỗi tài khoản trong file cũ được ghi vào
+// migration để ví faucet chuyển lại bằng giao dịch thật (xem PayLegacyBalances). File cũ
+// được giữ lại với tên blockchain.v<version>.json.
+func (bc *Blockchain) migrateLegacySnapshot(data []byte, version int) error {
+	var legacy legacySnapshot
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return err
+	}
+
+	credits, err := legacy.credits()
+	if err != nil {
+		return err
+	}
+
+	backup := fmt.Sprintf("blockchain.v%d.json", version)
+	if err := os.WriteFile(backup, data, 0644); err != nil {
+		return fmt.Errorf("back up blockchain.json: %v", err)
+	}
+
+	bc.Version = snapshotVersion
+	bc.migration = credits
+	if err := bc.SaveToFile(); err != nil {
+		return err
+	}
+
+	log.Printf("Migrated blockchain.json version %d to a new chain: %d legacy balances will be paid by the faucet, old file kept as %s",
+		version, len(credits), backup)
+	return nil
+}
+
+// PayLegacyBalances chuyển các số dư còn chờ của blockchain.json cũ (xem migrateLegacySnapshot)
+// từ ví faucet, trong một block do faucet tạo và ký chứa tối đa maxBlockTransactions số dư.
+// Không làm gì nếu không còn số dư chờ chuyển hoặc faucet chưa qua validatorCooldown; block
+// producer gọi lại hàm này cho đến khi chuyển hết.
+func (bc *Blockchain) PayLegacyBalances(faucet *wallet.Wallet) error {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	if len(bc.migration) == 0 {
+		return nil
+	}
+
+	validator, err := bc.StakingPool.GetValidatorInfo(faucet.Address)
+	if err != nil {
+		return fmt.Errorf("faucet cannot pay legacy balances: %v", err)
+	}
+	if time.Now().Unix()-validator.LastBlockTime < validatorCooldown {
+		return nil
+	}
+
+	addresses := make([]string, 0, len(bc.migration))
+	var total coin.Amount
+	for address, amount := range bc.migration {
+		addresses = append(addresses, address)
+		var err error
+		if total, err = total.Add(amount); err != nil {
+			return err
+		}
+	}
+	sort.Strings(addresses)
+
+	// Giao dịch đang chờ của faucet (ví dụ coin cho ví mới) đi trước trong cùng block, các
+	// giao dịch chuyển số dư cũ dùng nonce tiếp theo để không làm giao dịch đang chờ mất hiệu lực
+	nonce := bc.Nonces[faucet.Address]
+	var transactions []*pool.Transaction
+	for _, tx := range bc.TxPool.GetBySender(faucet.Address) {
+		if tx.Nonce != nonce || len(transactions) == maxBlockTransactions-1 {
+			break
+		}
+		if total, err = coin.Sum(total, tx.Amount, tx.Fee); err != nil {
+			return err
+		}
+		transactions = append(transactions, tx)
+		nonce++
+	}
+
+	if balance := bc.Balances[faucet.Address]; balance < total {
+		return fmt.Errorf("faucet balance %s MYC cannot cover %s MYC of legacy balances and pending transfers", balance, total)
+	}
+
+	batch := addresses[:min(len(addresses), maxBlockTransactions-len(transactions))]
+	for i, address := range batch {
+		tx := pool.NewTransaction(faucet.Address, address, bc.migration[address], 0, nonce+uint64(i))
+		if err := tx.SignTransaction(faucet.PrivateKey); err != nil {
+			return err
+		}
+		transactions = append(transactions, tx)
+	}
+
+	block, err := bc.sealBlock(faucet.Address, transactions)
+	if err != nil {
+		return err
+	}
+	if err := block.Sign(faucet); err != nil {
+		return err
+	}
+
+	if err := bc.commitBlock(block); err != nil {
+		return err
+	}
+	// Chỉ bỏ khỏi migration khi block đã vào chain, trước khi lưu file cùng block để số dư
+	// không bị chuyển hai lần
+	for _, address := range batch {
+		delete(bc.migration, address)
+	}
+	bc.saveChain()
+	log.Printf("Paid %d legacy balances in block #%d, %d remaining", len(batch), block.Index, len(bc.migration))
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package blockchain
+
+// Các hàm dưới đây phục vụ lớp P2P (internal/p2p): nhận biết chain của peer và lấy
+// block nguyên bản (không phải bản trình bày cho API) để gửi qua mạng.
+
+// GenesisHash là hash của genesis block; hai node chỉ cùng chain khi có cùng genesis hash
+func (bc *Blockchain) GenesisHash() string {
+	return bc.genesis.Hash
+}
+
+// HasBlock cho biết node đã có block hash, trong chain chính hoặc trên nhánh phụ
+func (bc *Blockchain) HasBlock(hash string) bool {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	return bc.hasBlock(hash)
+}
+
+func (bc *Blockchain) hasBlock(hash string) bool {
+	if _, exists := bc.blockIndex[hash]; exists {
+		return true
+	}
+	_, exists := bc.sideBlocks[hash]
+	return exists
+}
+
+// BlockAt trả về block tại index trong chain
+func (bc *Blockchain) BlockAt(index int64) (*Block, bool) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	if index < 0 || index >= int64(len(bc.Chain)) {
+		return nil, false
+	}
+	return bc.Chain[index], true
+}
+
+// BlockByHash trả về block có hash cho trước trong chain chính
+func (bc *Blockchain) BlockByHash(hash string) (*Block, bool) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	index, exists := bc.blockIndex[hash]
+	if !exists {
+		return nil, false
+	}
+	return bc.Chain[index], true
+}
+
+// Locator trả về hash các block từ block mới nhất lùi về genesis: 10 block gần nhất rồi
+// khoảng cách nhân đôi, luôn kết thúc bằng genesis. Peer tìm hash đầu tiên có trong chain
+// của mình để biết hai chain bắt đầu khác nhau từ đâu.
+func (bc *Blockchain) Locator() []string {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	var locator []string
+	step := int64(1)
+	for index := int64(len(bc.Chain)) - 1; index > 0; index -= step {
+		locator = append(locator, bc.Chain[index].Hash)
+		if len(locator) >= 10 {
+			step *= 2
+		}
+	}
+	return append(locator, bc.Chain[0].Hash)
+}
+
+// BlocksAfter trả về tối đa limit block nối tiếp hash đầu tiên của locator có trong chain
+// (genesis nếu không hash nào có trong chain)
+func (bc *Blockchain) BlocksAfter(locator []string, limit int) []*Block {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	start := int64(1)
+	for _, hash := range locator {
+		if index, exists := bc.blockIndex[hash]; exists {
+			start = index + 1
+			break
+		}
+	}
+
+	var blocks []*Block
+	for index := start; index < int64(len(bc.Chain)) && len(blocks) < limit; index++ {
+		blocks = append(blocks, bc.Chain[index])
+	}
+	return blocks
+}
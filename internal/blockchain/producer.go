@@ -0,0 +1,48 @@
+package blockchain
+
+import (
+	"MyCoinApp/internal/wallet"
+	"log"
+	"time"
+)
+
+// StartBlockProducer chạy nền, định kỳ tạo block bằng ví validatorWallet khi
+// có giao dịch đang chờ (ví dụ coin faucet cho ví mới) và validator đã hết cooldown.
+func (bc *Blockchain) StartBlockProducer(validatorWallet *wallet.Wallet, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := bc.PayLegacyBalances(validatorWallet); err != nil {
+				log.Printf("Block producer: %v", err)
+			}
+			if !bc.canProduce(validatorWallet.Address) {
+				continue
+			}
+
+			block, err := bc.MinePendingTransactions(validatorWallet)
+			if err != nil {
+				log.Printf("Block producer: %v", err)
+				continue
+			}
+			log.Printf("Block producer created block #%d with %d transactions", block.Index, len(block.Transactions))
+		}
+	}()
+}
+
+// canProduce kiểm tra có giao dịch đang chờ và validator đã qua validatorCooldown
+func (bc *Blockchain) canProduce(address string) bool {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	if bc.TxPool.Len() == 0 {
+		return false
+	}
+
+	validator, err := bc.StakingPool.GetValidatorInfo(address)
+	if err != nil || !validator.IsActive {
+		return false
+	}
+
+	return time.Now().Unix()-validator.LastBlockTime >= validatorCooldown
+}
@@ -0,0 +1,96 @@
+package blockchain
+
+import (
+	"MyCoinApp/internal/coin"
+	"MyCoinApp/internal/consensus"
+	"MyCoinApp/internal/models"
+	"MyCoinApp/internal/pool"
+	"fmt"
+	"log"
+)
+
+// snapshot là nội dung của blockchain.json. Block và giao dịch đang chờ được lưu
+// bằng bản mã hóa nhị phân chuẩn (base64 trong JSON) để nạp lại đúng từng byte đã hash.
+// Balances, Nonces và StakingPool chỉ dùng để đối chiếu khi dựng lại state.
+type snapshot struct {
+	Version             int                    `json:"version"`
+	Blocks              [][]byte               `json:"blocks"`
+	PendingTransactions [][]byte               `json:"pending_transactions"`
+	MiningReward        coin.Amount            `json:"mining_reward"`
+	Balances            map[string]coin.Amount `json:"balances"`
+	Nonces              map[string]uint64      `json:"nonces"`
+	StakingPool         *consensus.StakingPool `json:"staking_pool"`
+	// Migration là số dư của blockchain.json phiên bản cũ mà ví faucet chưa chuyển lại
+	Migration map[string]coin.Amount `json:"migration,omitempty"`
+	// LegacyHeight là migration point của chain (xem Blockchain.legacyHeight)
+	LegacyHeight int64 `json:"legacy_height,omitempty"`
+}
+
+func (bc *Blockchain) toSnapshot() (*snapshot, error) {
+	snap := &snapshot{
+		Version:             snapshotVersion,
+		Blocks:              make([][]byte, 0, len(bc.Chain)),
+		PendingTransactions: make([][]byte, 0, bc.TxPool.Len()),
+		MiningReward:        bc.MiningReward,
+		Balances:            bc.Balances,
+		Nonces:              bc.Nonces,
+		StakingPool:         bc.StakingPool,
+		Migration:           bc.migration,
+		LegacyHeight:        bc.legacyHeight,
+	}
+
+	for _, block := range bc.Chain {
+		data, err := block.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		snap.Blocks = append(snap.Blocks, data)
+	}
+
+	for _, tx := range bc.TxPool.GetTransactions() {
+		data, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		snap.PendingTransactions = append(snap.PendingTransactions, data)
+	}
+
+	return snap, nil
+}
+
+// restoreSnapshot giải mã block và giao dịch đang chờ của snap vào bc
+func (bc *Blockchain) restoreSnapshot(snap *snapshot) error {
+	chain := make([]*Block, 0, len(snap.Blocks))
+	for i, data := range snap.Blocks {
+		block, err := DecodeBlock(data)
+		if err != nil {
+			return fmt.Errorf("block %d: %v", i, err)
+		}
+		chain = append(chain, block)
+	}
+
+	pending := make([]*pool.Transaction, 0, len(snap.PendingTransactions))
+	for i, data := range snap.PendingTransactions {
+		tx, err := pool.DecodeTransaction(data)
+		if err != nil {
+			return fmt.Errorf("pending transaction %d: %v", i, err)
+		}
+		pending = append(pending, tx)
+	}
+
+	bc.Version = snap.Version
+	bc.Chain = chain
+	for _, tx := range pending {
+		if _, err := bc.TxPool.AddTransaction(tx); err != nil {
+			log.Printf("Dropping pending transaction %s: %v", tx.Hash, err)
+			bc.recordDropped(tx, models.TxStatusDropped, err.Error())
+		}
+	}
+	bc.MiningReward = snap.MiningReward
+	bc.Balances = snap.Balances
+	bc.Nonces = snap.Nonces
+	bc.StakingPool = snap.StakingPool
+	bc.migration = snap.Migration
+	bc.legacyHeight = max(snap.LegacyHeight, legacyHeightOf(chain))
+	return nil
+}
@@ -0,0 +1,58 @@
+package blockchain
+
+import (
+	"MyCoinApp/internal/models"
+	"MyCoinApp/internal/pool"
+	"fmt"
+	"log"
+	"time"
+)
+
+// StartMempoolSweeper chạy nền, định kỳ dọn khỏi pool các giao dịch hết hạn,
+// có nonce đã dùng, không còn hợp lệ hoặc nằm trong pool lâu hơn ttl.
+func (bc *Blockchain) StartMempoolSweeper(interval, ttl time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for range ticker.C {
+			bc.SweepMempool(ttl)
+		}
+	}()
+}
+
+// SweepMempool dọn pool một lần, ghi log lý do của từng giao dịch bị dọn
+// và trả về danh sách giao dịch bị dọn
+func (bc *Blockchain) SweepMempool(ttl time.Duration) []pool.Eviction {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	nextHeight := int64(len(bc.Chain))
+	evictions := bc.TxPool.Sweep(func(tx *pool.Transaction, age time.Duration) string {
+		if tx.IsExpired(nextHeight) {
+			return fmt.Sprintf("expired at height %d, next block is %d", tx.ValidUntilHeight, nextHeight)
+		}
+		if nonce := bc.Nonces[tx.From]; tx.Nonce < nonce {
+			return fmt.Sprintf("nonce %d already used, next nonce is %d", tx.Nonce, nonce)
+		}
+		if err := validateTransaction(tx); err != nil {
+			return err.Error()
+		}
+		if age > ttl {
+			return fmt.Sprintf("pending for %s, longer than mempool ttl %s", age.Round(time.Second), ttl)
+		}
+		return ""
+	})
+
+	for _, eviction := range evictions {
+		log.Printf("Mempool sweeper evicted %s from %s (nonce %d): %s",
+			eviction.Transaction.Hash, eviction.Transaction.From, eviction.Transaction.Nonce, eviction.Reason)
+		bc.recordDropped(eviction.Transaction, models.TxStatusDropped, eviction.Reason)
+	}
+	if len(evictions) > 0 {
+		// Pool được lưu cùng chain, ghi lại để giao dịch bị dọn không quay lại khi khởi động
+		if err := bc.SaveToFile(); err != nil {
+			log.Printf("WARNING: Failed to save blockchain: %v", err)
+		}
+	}
+	return evictions
+}
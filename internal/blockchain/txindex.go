@@ -0,0 +1,137 @@
+package blockchain
+
+import (
+	"MyCoinApp/internal/models"
+	"MyCoinApp/internal/pool"
+)
+
+// maxDroppedRecords là số giao dịch bị loại/từ chối gần nhất được giữ lại để tra cứu
+const maxDroppedRecords = 10000
+
+// txLocation là vị trí của giao dịch đã xác nhận trong chain
+type txLocation struct {
+	BlockIndex int64
+	Position   int
+}
+
+// droppedRecord ghi lại giao dịch bị loại khỏi pool hoặc bị từ chối cùng lý do
+type droppedRecord struct {
+	Status      string
+	Reason      string
+	Transaction *pool.Transaction
+}
+
+// droppedLog giữ tối đa maxDroppedRecords bản ghi, bỏ bản ghi cũ nhất khi đầy
+type droppedLog struct {
+	records map[string]*droppedRecord
+	order   []string
+}
+
+func newDroppedLog() *droppedLog {
+	return &droppedLog{records: make(map[string]*droppedRecord)}
+}
+
+func (d *droppedLog) add(tx *pool.Transaction, status, reason string) {
+	if tx == nil || tx.Hash == "" {
+		return
+	}
+
+	if _, exists := d.records[tx.Hash]; !exists {
+		if len(d.order) >= maxDroppedRecords {
+			delete(d.records, d.order[0])
+			d.order = d.order[1:]
+		}
+		d.order = append(d.order, tx.Hash)
+	}
+	d.records[tx.Hash] = &droppedRecord{Status: status, Reason: reason, Transaction: tx}
+}
+
+// reindexTransactions dựng lại chỉ mục hash → vị trí của block và giao dịch từ toàn bộ chain
+func (bc *Blockchain) reindexTransactions() {
+	bc.blockIndex = make(map[string]int64)
+	bc.txIndex = make(map[string]txLocation)
+	bc.addressIndex = make(map[string][]txLocation)
+	for _, block := range bc.Chain {
+		bc.indexBlock(block)
+	}
+}
+
+// indexBlock thêm block vừa nối vào chain và các giao dịch của nó vào chỉ mục
+func (bc *Blockchain) indexBlock(block *Block) {
+	bc.blockIndex[block.Hash] = block.Index
+	for i, tx := range block.Transactions {
+		location := txLocation{BlockIndex: block.Index, Position: i}
+		bc.txIndex[tx.Hash] = location
+		bc.indexAddresses(tx, location)
+	}
+}
+
+// recordDropped ghi lại giao dịch bị loại khỏi pool (status dropped) hoặc bị từ chối (status rejected)
+// và phát sự kiện cho giao dịch bị loại. Giao dịch có Hash không khớp nội dung không được ghi để
+// không ai mượn hash của giao dịch khác, và trạng thái đang chờ hay đã xác nhận không bị ghi đè.
+func (bc *Blockchain) recordDropped(tx *pool.Transaction, status, reason string) {
+	if tx == nil || tx.Hash != tx.CalculateHash() {
+		return
+	}
+	if _, confirmed := bc.txIndex[tx.Hash]; confirmed {
+		return
+	}
+	if _, pending := bc.TxPool.GetTransaction(tx.Hash); pending {
+		return
+	}
+
+	bc.dropped.add(tx, status, reason)
+	if status == models.TxStatusDropped {
+		bc.publishTransaction(eventTransactionDropped, tx, status, reason)
+	}
+}
+
+// findTransaction tìm giao dịch đã xác nhận theo hash
+func (bc *Blockchain) findTransaction(hash string) (*Block, int, bool) {
+	location, exists := bc.txIndex[hash]
+	if !exists {
+		return nil, 0, false
+	}
+	block := bc.Chain[location.BlockIndex]
+	return block, location.Position, true
+}
+
+// GetTransactionStatus trả về trạng thái của giao dịch có hash: đã xác nhận, đang chờ,
+// bị loại hoặc bị từ chối. Trả về false nếu node không biết giao dịch này.
+func (bc *Blockchain) GetTransactionStatus(hash string) (*models.TransactionStatusResponse, bool) {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	if block, position, found := bc.findTransaction(hash); found {
+		return &models.TransactionStatusResponse{
+			Hash:        hash,
+			Status:      models.TxStatusConfirmed,
+			Transaction: block.Transactions[position],
+			Receipt: &models.TransactionReceipt{
+				BlockIndex:    block.Index,
+				BlockHash:     block.Hash,
+				Position:      position,
+				Confirmations: int64(len(bc.Chain)) - block.Index,
+			},
+		}, true
+	}
+
+	if tx, found := bc.TxPool.GetTransaction(hash); found {
+		return &models.TransactionStatusResponse{
+			Hash:        hash,
+			Status:      models.TxStatusPending,
+			Transaction: tx,
+		}, true
+	}
+
+	if record, found := bc.dropped.records[hash]; found {
+		return &models.TransactionStatusResponse{
+			Hash:        hash,
+			Status:      record.Status,
+			Transaction: record.Transaction,
+			Reason:      record.Reason,
+		}, true
+	}
+
+	return nil, false
+}
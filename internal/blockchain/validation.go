@@ -0,0 +1,379 @@
+package blockchain
+
+import (
+	"MyCoinApp/internal/coin"
+	"MyCoinApp/internal/consensus"
+	"MyCoinApp/internal/models"
+	"MyCoinApp/internal/pool"
+	"fmt"
+	"sort"
+)
+
+// genesisTimestamp là timestamp cố định của genesis block (01/01/2021)
+const genesisTimestamp = 1609459200
+
+// genesisAllocation là tổng số coin phát hành ở genesis cho tài khoản faucet
+const genesisAllocation = 1000000 * coin.Unit
+
+// genesisValidatorStake là số coin faucet stake sẵn ở genesis để chain có validator đầu tiên
+const genesisValidatorStake = 100 * coin.Unit
+
+// newGenesisStakeTransaction tạo giao dịch stake của faucet trong genesis block.
+// Giao dịch này không cần chữ ký vì genesis block được so khớp nguyên vẹn.
+// Giống NewGenesisTransaction, luôn dùng TxVersion1 để hash của genesis không đổi.
+func newGenesisStakeTransaction(genesisAddress string) *pool.Transaction {
+	tx := &pool.Transaction{
+		Version:   pool.TxVersion1,
+		Type:      pool.TxStake,
+		From:      genesisAddress,
+		Amount:    genesisValidatorStake,
+		Timestamp: genesisTimestamp,
+	}
+	tx.Hash = tx.CalculateHash()
+	return tx
+}
+
+// chainState là trạng thái tài khoản và staking được dựng lại từ các block
+type chainState struct {
+	Balances    map[string]coin.Amount
+	Nonces      map[string]uint64
+	StakingPool *consensus.StakingPool
+}
+
+// newChainState tạo state rỗng với feePolicy là cách chia phí của chain
+func newChainState(feePolicy consensus.FeePolicy) *chainState {
+	stakingPool := consensus.NewStakingPool()
+	stakingPool.FeePolicy = feePolicy
+	return &chainState{
+		Balances:    make(map[string]coin.Amount),
+		Nonces:      make(map[string]uint64),
+		StakingPool: stakingPool,
+	}
+}
+
+// clone tạo bản sao để áp dụng thử block mà không làm hỏng state hiện tại
+func (st *chainState) clone() *chainState {
+	clone := &chainState{
+		Balances:    make(map[string]coin.Amount, len(st.Balances)),
+		Nonces:      make(map[string]uint64, len(st.Nonces)),
+		StakingPool: st.StakingPool.Clone(),
+	}
+	for address, balance := range st.Balances {
+		clone.Balances[address] = balance
+	}
+	for address, nonce := range st.Nonces {
+		clone.Nonces[address] = nonce
+	}
+	return clone
+}
+
+func (st *chainState) credit(address string, amount coin.Amount) error {
+	balance, err := st.Balances[address].Add(amount)
+	if err != nil {
+		return fmt.Errorf("balance of %s: %v", address, err)
+	}
+	st.Balances[address] = balance
+	return nil
+}
+
+func (st *chainState) debit(address string, amount coin.Amount) error {
+	balance, err := st.Balances[address].Sub(amount)
+	if err != nil {
+		return fmt.Errorf("insufficient balance for %s", address)
+	}
+	st.Balances[address] = balance
+	return nil
+}
+
+// applyBlock áp dụng mọi giao dịch trong block vào số dư, nonce và StakingPool
+func (st *chainState) applyBlock(block *Block) error {
+	for _, tx := range block.Transactions {
+		if err := st.applyTransaction(block, tx); err != nil {
+			return fmt.Errorf("transaction %s: %v", tx.Hash, err)
+		}
+	}
+	return nil
+}
+
+// applyTransaction áp dụng một giao dịch của block. Thay đổi stake có hiệu lực từ block kế tiếp.
+func (st *chainState) applyTransaction(block *Block, tx *pool.Transaction) error {
+	switch tx.Type {
+	case pool.TxGenesis:
+		if block.Index != 0 {
+			return fmt.Errorf("genesis transaction outside the genesis block")
+		}
+		return st.credit(tx.To, tx.Amount)
+
+	case pool.TxReward:
+		if err := st.credit(tx.To, tx.Amount); err != nil {
+			return err
+		}
+		// Phần phí bị đốt không được cộng cho ai
+		if tx.Fees != nil && !tx.Fees.TreasuryAmount.IsZero() {
+			if err := st.credit(tx.Fees.Treasury, tx.Fees.TreasuryAmount); err != nil {
+				return err
+			}
+		}
+		if err := st.StakingPool.RewardValidator(block.Validator, tx.Amount, block.Timestamp); err != nil {
+			return fmt.Errorf("reward validator %s: %v", block.Validator, err)
+		}
+		return nil
+
+	case pool.TxTransfer:
+		if err := st.debitSender(tx); err != nil {
+			return err
+		}
+		return st.credit(tx.To, tx.Amount)
+
+	case pool.TxStake:
+		if err := st.debitSender(tx); err != nil {
+			return err
+		}
+		return st.StakingPool.AddValidator(tx.From, tx.Amount, block.Index+1, block.Timestamp)
+
+	case pool.TxUnstake:
+		validator, err := st.StakingPool.GetValidatorInfo(tx.From)
+		if err != nil {
+			return err
+		}
+		refund := validator.StakedAmount
+		if err := st.debitSender(tx); err != nil {
+			return err
+		}
+		if err := st.StakingPool.RemoveValidator(tx.From, block.Index+1); err != nil {
+			return err
+		}
+		return st.credit(tx.From, refund)
+	}
+
+	return fmt.Errorf("unknown transaction type %q", tx.Type)
+}
+
+// debitSender trừ số coin và phí của giao dịch khỏi người gửi và tăng nonce của người gửi
+func (st *chainState) debitSender(tx *pool.Transaction) error {
+	cost, err := tx.Cost()
+	if err != nil {
+		return err
+	}
+	if err := st.debit(tx.From, cost); err != nil {
+		return err
+	}
+	st.Nonces[tx.From] = tx.Nonce + 1
+	return nil
+}
+
+// matches so sánh state dựng lại từ chain với snapshot nạp từ file.
+// Các tài khoản có số dư hoặc nonce bằng 0 được coi như không tồn tại.
+func (st *chainState) matches(snapshot *chainState) error {
+	for _, address := range unionKeys(st.Balances, snapshot.Balances) {
+		if st.Balances[address] != snapshot.Balances[address] {
+			return fmt.Errorf("balance of %s is %s MYC, chain says %s MYC",
+				address, snapshot.Balances[address], st.Balances[address])
+		}
+	}
+
+	for _, address := range unionKeys(st.Nonces, snapshot.Nonces) {
+		if st.Nonces[address] != snapshot.Nonces[address] {
+			return fmt.Errorf("nonce of %s is %d, chain says %d",
+				address, snapshot.Nonces[address], st.Nonces[address])
+		}
+	}
+
+	if snapshot.StakingPool == nil {
+		return fmt.Errorf("staking pool is missing")
+	}
+	for _, address := range unionKeys(st.StakingPool.Validators, snapshot.StakingPool.Validators) {
+		expected, stored := st.StakingPool.Validators[address], snapshot.StakingPool.Validators[address]
+		if expected == nil || stored == nil {
+			return fmt.Errorf("validator set differs at %s", address)
+		}
+		if expected.StakedAmount != stored.StakedAmount || expected.IsActive != stored.IsActive ||
+			expected.SlashCount != stored.SlashCount || expected.TotalRewards != stored.TotalRewards {
+			return fmt.Errorf("validator %s differs from the chain", address)
+		}
+	}
+
+	return nil
+}
+
+// unionKeys trả về các khóa (đã sắp xếp) xuất hiện trong a hoặc b
+func unionKeys[V any](a, b map[string]V) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	keys := make([]string, 0, len(a)+len(b))
+	for _, m := range []map[string]V{a, b} {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ValidateChain replay toàn bộ chain từ genesis block: kiểm tra hash, liên kết,
+// validator tạo block, chữ ký và nonce của giao dịch, số dư của người gửi và
+// giao dịch thưởng của mỗi block. Trả về block lỗi đầu tiên cùng lý do.
+func (bc *Blockchain) ValidateChain() *models.ValidationResult {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	_, result := bc.replayChain(nil)
+	return result
+}
+
+// replayChain dựng lại state từ genesis block của node bằng cách áp dụng lần lượt các block.
+// visit (nếu có) được gọi với state sau mỗi block và không được sửa state đó.
+func (bc *Blockchain) replayChain(visit func(block *Block, state *chainState)) (*chainState, *models.ValidationResult) {
+	result := &models.ValidationResult{InvalidBlock: -1}
+	fail := func(block *Block, err error) (*chainState, *models.ValidationResult) {
+		result.InvalidBlock = block.Index
+		result.InvalidHash = block.Hash
+		result.Reason = err.Error()
+		return nil, result
+	}
+
+	if len(bc.Chain) == 0 {
+		result.Reason = "chain has no genesis block"
+		return nil, result
+	}
+
+	genesis := bc.Chain[0]
+	if genesis.Index != 0 || genesis.Hash != bc.genesis.Hash || genesis.Hash != genesis.CalculateHash() {
+		return fail(genesis, fmt.Errorf("genesis block does not match"))
+	}
+	if err := genesis.VerifyMerkleRoot(); err != nil {
+		return fail(genesis, err)
+	}
+
+	state := newChainState(bc.feePolicy)
+	if err := state.applyBlock(genesis); err != nil {
+		return fail(genesis, err)
+	}
+	if visit != nil {
+		visit(genesis, state)
+	}
+	result.CheckedBlocks = 1
+
+	for i := 1; i < len(bc.Chain); i++ {
+		block := bc.Chain[i]
+		previousBlock := bc.Chain[i-1]
+
+		if block.Index != previousBlock.Index+1 {
+			return fail(block, fmt.Errorf("block index %d does not follow %d", block.Index, previousBlock.Index))
+		}
+
+		if block.PreviousHash != previousBlock.Hash {
+			return fail(block, fmt.Errorf("previous hash does not match block %d", previousBlock.Index))
+		}
+
+		if err := bc.validateBlockProducer(block, state.StakingPool); err != nil {
+			return fail(block, err)
+		}
+
+		if err := validateBlockTime(block, previousBlock, state.StakingPool); err != nil {
+			return fail(block, err)
+		}
+
+		if err := validateBlockTransactions(block, state.Nonces); err != nil {
+			return fail(block, err)
+		}
+
+		if err := validateBlockReward(block, state.StakingPool, bc.isLegacyHeight(block.Index)); err != nil {
+			return fail(block, err)
+		}
+
+		if err := state.applyBlock(block); err != nil {
+			return fail(block, err)
+		}
+		if visit != nil {
+			visit(block, state)
+		}
+
+		result.CheckedBlocks++
+	}
+
+	result.Valid = true
+	return state, result
+}
+
+// blockFees tính tổng phí của các giao dịch người dùng trong block
+func blockFees(transactions []*pool.Transaction) (coin.Amount, error) {
+	var fees coin.Amount
+	for _, tx := range transactions {
+		if tx.IsSystem() {
+			continue
+		}
+		var err error
+		if fees, err = fees.Add(tx.Fee); err != nil {
+			return 0, err
+		}
+	}
+	return fees, nil
+}
+
+// distributeFees chia tổng phí fees theo FeePolicy của stakingPool
+func distributeFees(fees coin.Amount, stakingPool *consensus.StakingPool) (*pool.FeeDistribution, error) {
+	policy := stakingPool.FeePolicy
+	validator, burned, treasury, err := policy.Split(fees)
+	if err != nil {
+		return nil, err
+	}
+
+	distribution := &pool.FeeDistribution{
+		Collected:      fees,
+		Validator:      validator,
+		Burned:         burned,
+		TreasuryAmount: treasury,
+	}
+	if !treasury.IsZero() {
+		distribution.Treasury = policy.TreasuryAddress
+	}
+	return distribution, nil
+}
+
+// validateBlockReward kiểm tra block có đúng một giao dịch thưởng, trả BlockReward cộng
+// phần phí của validator và ghi lại cách chia phí đúng với FeePolicy. Với block dưới
+// migration point (legacy), giao dịch thưởng TxVersion1 trong block BlockVersion1 (tạo
+// trước khi có chia phí, không chứa được Fees) theo luật cũ: chỉ trả BlockReward và phí
+// của block không được trả cho ai.
+func validateBlockReward(block *Block, stakingPool *consensus.StakingPool, legacy bool) error {
+	fees, err := blockFees(block.Transactions)
+	if err != nil {
+		return err
+	}
+	expectedFees, err := distributeFees(fees, stakingPool)
+	if err != nil {
+		return err
+	}
+	expectedAmount, err := stakingPool.BlockReward.Add(expectedFees.Validator)
+	if err != nil {
+		return err
+	}
+
+	rewards := 0
+	for _, tx := range block.Transactions {
+		if !tx.IsReward() {
+			continue
+		}
+		rewards++
+		if legacy && tx.Version == pool.TxVersion1 && block.Version == BlockVersion1 {
+			if tx.Amount != stakingPool.BlockReward {
+				return fmt.Errorf("reward transaction pays %s MYC, expected %s MYC", tx.Amount, stakingPool.BlockReward)
+			}
+			continue
+		}
+		if tx.Fees == nil || *tx.Fees != *expectedFees {
+			return fmt.Errorf("reward transaction does not distribute %s MYC of fees according to the fee policy", fees)
+		}
+		if tx.Amount != expectedAmount {
+			return fmt.Errorf("reward transaction pays %s MYC, expected %s MYC", tx.Amount, expectedAmount)
+		}
+	}
+
+	if rewards != 1 {
+		return fmt.Errorf("block must contain exactly one reward transaction, found %d", rewards)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+// Package codec cài đặt mã hóa nhị phân chuẩn (canonical) dùng để hash, ký và
+// lưu giao dịch và block. Số nguyên được ghi big-endian với độ dài cố định,
+// chuỗi và mảng byte có tiền tố độ dài dạng uvarint nên ranh giới giữa các
+// trường luôn rõ ràng.
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// MaxFieldSize giới hạn độ dài của một trường khi giải mã để dữ liệu hỏng
+// không làm cấp phát bộ nhớ quá lớn
+const MaxFieldSize = 16 << 20
+
+// Writer ghi các trường theo thứ tự vào bộ đệm
+type Writer struct {
+	buf bytes.Buffer
+}
+
+func NewWriter() *Writer {
+	return &Writer{}
+}
+
+func (w *Writer) WriteUint8(v uint8) {
+	w.buf.WriteByte(v)
+}
+
+func (w *Writer) WriteUint64(v uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	w.buf.Write(b[:])
+}
+
+func (w *Writer) WriteInt64(v int64) {
+	w.WriteUint64(uint64(v))
+}
+
+// WriteUvarint ghi số đếm (số phần tử, độ dài) dạng uvarint
+func (w *Writer) WriteUvarint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	w.buf.Write(b[:n])
+}
+
+func (w *Writer) WriteBytes(v []byte) {
+	w.WriteUvarint(uint64(len(v)))
+	w.buf.Write(v)
+}
+
+func (w *Writer) WriteString(v string) {
+	w.WriteUvarint(uint64(len(v)))
+	w.buf.WriteString(v)
+}
+
+func (w *Writer) Bytes() []byte {
+	return w.buf.Bytes()
+}
+
+// Reader đọc các trường theo đúng thứ tự đã ghi. Lỗi đầu tiên được giữ lại
+// và các lần đọc sau trả về giá trị rỗng, kiểm tra Err() sau khi đọc xong.
+type Reader struct {
+	r   *bytes.Reader
+	err error
+}
+
+func NewReader(data []byte) *Reader {
+	return &Reader{r: bytes.NewReader(data)}
+}
+
+func (r *Reader) fail(field string, err error) {
+	if r.err == nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		r.err = fmt.Errorf("decode %s: %v", field, err)
+	}
+}
+
+func (r *Reader) ReadUint8(field string) uint8 {
+	if r.err != nil {
+		return 0
+	}
+	v, err := r.r.ReadByte()
+	if err != nil {
+		r.fail(field, err)
+		return 0
+	}
+	return v
+}
+
+func (r *Reader) ReadUint64(field string) uint64 {
+	if r.err != nil {
+		return 0
+	}
+	var b [8]byte
+	if _, err := io.ReadFull(r.r, b[:]); err != nil {
+		r.fail(field, err)
+		return 0
+	}
+	return binary.BigEndian.Uint64(b[:])
+}
+
+func (r *Reader) ReadInt64(field string) int64 {
+	return int64(r.ReadUint64(field))
+}
+
+func (r *Reader) ReadUvarint(field string) uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		r.fail(field, err)
+		return 0
+	}
+	return v
+}
+
+func (r *Reader) ReadBytes(field string) []byte {
+	length := r.ReadUvarint(field)
+	if r.err != nil {
+		return nil
+	}
+	if length > MaxFieldSize || length > uint64(r.r.Len()) {
+		r.fail(field, fmt.Errorf("length %d exceeds remaining data", length))
+		return nil
+	}
+	v := make([]byte, length)
+	if _, err := io.ReadFull(r.r, v); err != nil {
+		r.fail(field, err)
+		return nil
+	}
+	return v
+}
+
+func (r *Reader) ReadString(field string) string {
+	return string(r.ReadBytes(field))
+}
+
+// Remaining trả về số byte chưa đọc, dùng để kiểm tra số phần tử đọc từ dữ liệu trước khi cấp phát
+func (r *Reader) Remaining() int {
+	return r.r.Len()
+}
+
+// Err trả về lỗi đầu tiên khi đọc
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Finish báo lỗi nếu đọc lỗi hoặc còn dữ liệu thừa, vì mã hóa chuẩn không có byte thừa
+func (r *Reader) Finish() error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.r.Len() != 0 {
+		return fmt.Errorf("decode: %d trailing bytes", r.r.Len())
+	}
+	return nil
+}
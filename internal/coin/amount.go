@@ -0,0 +1,186 @@
+package coin
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// Decimals là số chữ số thập phân của MYC: 1 MYC = 10^8 đơn vị cơ sở
+const Decimals = 8
+
+// Unit là số đơn vị cơ sở trong 1 MYC
+const Unit Amount = 100000000
+
+// Amount là số lượng coin tính bằng đơn vị cơ sở (fixed-point, không âm).
+// Khi mã hóa JSON, Amount được ghi dưới dạng số thập phân MYC (ví dụ 10.5)
+// để giữ tương thích với client và file blockchain.json cũ.
+type Amount uint64
+
+// ErrOverflow được trả về khi phép tính vượt quá giới hạn của Amount
+var ErrOverflow = fmt.Errorf("amount overflow")
+
+// ErrInsufficient được trả về khi phép trừ cho kết quả âm
+var ErrInsufficient = fmt.Errorf("amount underflow")
+
+// ParseAmount đọc số MYC dạng thập phân ("10", "0.01"), tối đa Decimals chữ số sau dấu chấm
+func ParseAmount(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty amount")
+	}
+	if strings.HasPrefix(s, "-") {
+		return 0, fmt.Errorf("amount cannot be negative: %s", s)
+	}
+	if strings.ContainsAny(s, "eE") {
+		// Số dạng mũ (1e-05) do một số client JSON sinh ra
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount: %s", s)
+		}
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if len(frac) > Decimals {
+		return 0, fmt.Errorf("amount %s has more than %d decimals", s, Decimals)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	units, err := strconv.ParseUint(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount: %s", s)
+	}
+
+	var fracUnits uint64
+	if frac != "" {
+		fracUnits, err = strconv.ParseUint(frac+strings.Repeat("0", Decimals-len(frac)), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount: %s", s)
+		}
+	}
+
+	hi, lo := bits.Mul64(units, uint64(Unit))
+	if hi != 0 {
+		return 0, ErrOverflow
+	}
+	total, carry := bits.Add64(lo, fracUnits, 0)
+	if carry != 0 {
+		return 0, ErrOverflow
+	}
+
+	return Amount(total), nil
+}
+
+// MustParse giống ParseAmount nhưng panic khi lỗi, dùng cho hằng số cấu hình
+func MustParse(s string) Amount {
+	a, err := ParseAmount(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// FromFloat chuyển số MYC dạng float64 (dữ liệu cũ) sang Amount, làm tròn tới đơn vị cơ sở gần nhất
+func FromFloat(f float64) (Amount, error) {
+	if f < 0 || math.IsNaN(f) {
+		return 0, fmt.Errorf("invalid amount: %v", f)
+	}
+	units := math.Round(f * float64(Unit))
+	if units >= math.MaxUint64 {
+		return 0, ErrOverflow
+	}
+	return Amount(units), nil
+}
+
+// String trả về số MYC dạng thập phân ngắn nhất, ví dụ "10", "0.01"
+func (a Amount) String() string {
+	whole := uint64(a) / uint64(Unit)
+	frac := uint64(a) % uint64(Unit)
+	if frac == 0 {
+		return strconv.FormatUint(whole, 10)
+	}
+
+	fracStr := fmt.Sprintf("%0*d", Decimals, frac)
+	return strconv.FormatUint(whole, 10) + "." + strings.TrimRight(fracStr, "0")
+}
+
+// Float64 dùng để hiển thị hoặc log, không dùng cho tính toán
+func (a Amount) Float64() float64 {
+	return float64(a) / float64(Unit)
+}
+
+func (a Amount) IsZero() bool {
+	return a == 0
+}
+
+// Add cộng có kiểm tra tràn số
+func (a Amount) Add(b Amount) (Amount, error) {
+	sum, carry := bits.Add64(uint64(a), uint64(b), 0)
+	if carry != 0 {
+		return 0, ErrOverflow
+	}
+	return Amount(sum), nil
+}
+
+// Sub trừ có kiểm tra kết quả âm
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if b > a {
+		return 0, ErrInsufficient
+	}
+	return a - b, nil
+}
+
+// MulDiv tính a * num / den (làm tròn xuống) mà không bị tràn ở bước trung gian
+func (a Amount) MulDiv(num, den uint64) (Amount, error) {
+	if den == 0 {
+		return 0, fmt.Errorf("division by zero")
+	}
+	result := new(big.Int).SetUint64(uint64(a))
+	result.Mul(result, new(big.Int).SetUint64(num))
+	result.Quo(result, new(big.Int).SetUint64(den))
+	if !result.IsUint64() {
+		return 0, ErrOverflow
+	}
+	return Amount(result.Uint64()), nil
+}
+
+// Sum cộng dồn nhiều giá trị có kiểm tra tràn số
+func Sum(amounts ...Amount) (Amount, error) {
+	var total Amount
+	var err error
+	for _, a := range amounts {
+		total, err = total.Add(a)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON nhận cả số JSON (10.5) lẫn chuỗi ("10.5")
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	parsed, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
@@ -1,6 +1,7 @@
 package consensus
 
 import (
+	"MyCoinApp/internal/coin"
 	"crypto/rand"
 	"fmt"
 	"math/big"
@@ -9,38 +10,99 @@ import (
 )
 
 type Validator struct {
-	Address       string  `json:"address"`
-	StakedAmount  float64 `json:"staked_amount"`
-	LastBlockTime int64   `json:"last_block_time"`
-	SlashCount    int     `json:"slash_count"`
-	IsActive      bool    `json:"is_active"`
-	JoinTime      int64   `json:"join_time"`
-	TotalRewards  float64 `json:"total_rewards"`
+	Address       string      `json:"address"`
+	StakedAmount  coin.Amount `json:"staked_amount"`
+	LastBlockTime int64       `json:"last_block_time"`
+	SlashCount    int         `json:"slash_count"`
+	IsActive      bool        `json:"is_active"`
+	JoinTime      int64       `json:"join_time"`
+	TotalRewards  coin.Amount `json:"total_rewards"`
+}
+
+// StakeEventType là loại thay đổi stake được ghi vào lịch sử của StakingPool
+type StakeEventType string
+
+const (
+	StakeEventStake   StakeEventType = "stake"
+	StakeEventUnstake StakeEventType = "unstake"
+	StakeEventSlash   StakeEventType = "slash"
+)
+
+// StakeEvent ghi lại một thay đổi stake. Height là index của block đầu tiên
+// chịu ảnh hưởng của thay đổi này.
+type StakeEvent struct {
+	Type    StakeEventType `json:"type"`
+	Address string         `json:"address"`
+	Amount  coin.Amount    `json:"amount"`
+	Height  int64          `json:"height"`
+}
+
+// FeePolicy chia phí giao dịch của một block (theo phần trăm) giữa validator tạo block,
+// phần bị đốt và quỹ treasury. Phần lẻ do làm tròn thuộc về validator.
+type FeePolicy struct {
+	ValidatorPercent uint64 `json:"validator_percent"`
+	BurnPercent      uint64 `json:"burn_percent"`
+	TreasuryPercent  uint64 `json:"treasury_percent"`
+	TreasuryAddress  string `json:"treasury_address,omitempty"`
+}
+
+// DefaultFeePolicy trả toàn bộ phí cho validator tạo block
+func DefaultFeePolicy() FeePolicy {
+	return FeePolicy{ValidatorPercent: 100}
+}
+
+func (p FeePolicy) Validate() error {
+	if p.ValidatorPercent+p.BurnPercent+p.TreasuryPercent != 100 {
+		return fmt.Errorf("fee split must add up to 100 percent")
+	}
+	if p.TreasuryPercent > 0 && p.TreasuryAddress == "" {
+		return fmt.Errorf("treasury address is required when treasury percent is set")
+	}
+	return nil
+}
+
+// Split chia tổng phí fees thành phần của validator, phần đốt và phần của treasury
+func (p FeePolicy) Split(fees coin.Amount) (validator, burned, treasury coin.Amount, err error) {
+	if burned, err = fees.MulDiv(p.BurnPercent, 100); err != nil {
+		return 0, 0, 0, err
+	}
+	if treasury, err = fees.MulDiv(p.TreasuryPercent, 100); err != nil {
+		return 0, 0, 0, err
+	}
+	// burned + treasury <= fees vì tổng phần trăm bằng 100
+	validator = fees - burned - treasury
+	return validator, burned, treasury, nil
 }
 
 type StakingPool struct {
 	Validators      map[string]*Validator `json:"validators"`
-	MinStakeAmount  float64               `json:"min_stake_amount"`
+	History         []*StakeEvent         `json:"history"`
+	MinStakeAmount  coin.Amount           `json:"min_stake_amount"`
 	MaxValidators   int                   `json:"max_validators"`
-	SlashingPenalty float64               `json:"slashing_penalty"`
-	BlockReward     float64               `json:"block_reward"`
-	StakingReward   float64               `json:"staking_reward"`
+	SlashingPenalty uint64                `json:"slashing_penalty"` // phần trăm stake bị phạt mỗi lần slash
+	BlockReward     coin.Amount           `json:"block_reward"`
+	StakingReward   uint64                `json:"staking_reward"` // phần trăm thưởng stake mỗi năm
+	FeePolicy       FeePolicy             `json:"fee_policy"`
 }
 
 func NewStakingPool() *StakingPool {
 	return &StakingPool{
 		Validators:      make(map[string]*Validator),
-		MinStakeAmount:  10.0, // Minimum 10 MYC to become validator
-		MaxValidators:   100,  // Maximum 100 validators
-		SlashingPenalty: 10.0, // 10% penalty for malicious behavior
-		BlockReward:     5.0,  // 50 MYC reward for block creator
-		StakingReward:   5.0,  // 5% annual staking reward
+		History:         make([]*StakeEvent, 0),
+		MinStakeAmount:  10 * coin.Unit, // Minimum 10 MYC to become validator
+		MaxValidators:   100,            // Maximum 100 validators
+		SlashingPenalty: 10,             // 10% penalty for malicious behavior
+		BlockReward:     5 * coin.Unit,  // 5 MYC reward for block creator
+		StakingReward:   5,              // 5% annual staking reward
+		FeePolicy:       DefaultFeePolicy(),
 	}
 }
 
-func (sp *StakingPool) AddValidator(address string, stakeAmount float64) error {
+// AddValidator đăng ký validator mới, có hiệu lực từ block có index height.
+// joinTime là timestamp của block chứa giao dịch stake.
+func (sp *StakingPool) AddValidator(address string, stakeAmount coin.Amount, height int64, joinTime int64) error {
 	if stakeAmount < sp.MinStakeAmount {
-		return fmt.Errorf("minimum stake amount is %.2f MYC", sp.MinStakeAmount)
+		return fmt.Errorf("minimum stake amount is %s MYC", sp.MinStakeAmount)
 	}
 
 	if len(sp.Validators) >= sp.MaxValidators {
@@ -57,28 +119,96 @@ func (sp *StakingPool) AddValidator(address string, stakeAmount float64) error {
 		LastBlockTime: 0,
 		SlashCount:    0,
 		IsActive:      true,
-		JoinTime:      time.Now().Unix(),
+		JoinTime:      joinTime,
 		TotalRewards:  0,
 	}
 
 	sp.Validators[address] = validator
+	sp.recordEvent(StakeEventStake, address, stakeAmount, height)
 	return nil
 }
 
-func (sp *StakingPool) RemoveValidator(address string) error {
+// RemoveValidator gỡ validator, validator không còn được tạo block từ index height
+func (sp *StakingPool) RemoveValidator(address string, height int64) error {
 	validator, exists := sp.Validators[address]
 	if !exists {
 		return fmt.Errorf("validator not found")
 	}
 
 	// Return staked amount (minus any penalties)
-	penalty := validator.StakedAmount * (float64(validator.SlashCount) * sp.SlashingPenalty / 100.0)
-	_ = validator.StakedAmount - penalty // refund amount (handled by blockchain.go)
+	penalty, err := validator.StakedAmount.MulDiv(uint64(validator.SlashCount)*sp.SlashingPenalty, 100)
+	if err != nil {
+		return err
+	}
+	_ = penalty // refund amount (handled by blockchain.go)
 
 	delete(sp.Validators, address)
+	sp.recordEvent(StakeEventUnstake, address, validator.StakedAmount, height)
 	return nil
 }
 
+// Clone tạo bản sao độc lập của StakingPool để áp dụng thử một block
+func (sp *StakingPool) Clone() *StakingPool {
+	clone := *sp
+	clone.Validators = make(map[string]*Validator, len(sp.Validators))
+	for address, validator := range sp.Validators {
+		copied := *validator
+		clone.Validators[address] = &copied
+	}
+	clone.History = make([]*StakeEvent, len(sp.History))
+	copy(clone.History, sp.History)
+	return &clone
+}
+
+func (sp *StakingPool) recordEvent(eventType StakeEventType, address string, amount coin.Amount, height int64) {
+	sp.History = append(sp.History, &StakeEvent{
+		Type:    eventType,
+		Address: address,
+		Amount:  amount,
+		Height:  height,
+	})
+}
+
+// ValidatorAt dựng lại trạng thái của validator tại block có index height từ lịch sử stake.
+// Trả về lỗi nếu address không phải validator ở height đó.
+func (sp *StakingPool) ValidatorAt(address string, height int64) (*Validator, error) {
+	var validator *Validator
+	for _, event := range sp.History {
+		if event.Address != address || event.Height > height {
+			continue
+		}
+
+		switch event.Type {
+		case StakeEventStake:
+			validator = &Validator{
+				Address:      address,
+				StakedAmount: event.Amount,
+				IsActive:     true,
+			}
+		case StakeEventUnstake:
+			validator = nil
+		case StakeEventSlash:
+			if validator == nil {
+				continue
+			}
+			stakedAmount, err := validator.StakedAmount.Sub(event.Amount)
+			if err != nil {
+				stakedAmount = 0
+			}
+			validator.StakedAmount = stakedAmount
+			validator.SlashCount++
+			if validator.SlashCount >= 3 {
+				validator.IsActive = false
+			}
+		}
+	}
+
+	if validator == nil {
+		return nil, fmt.Errorf("%s is not a validator at height %d", address, height)
+	}
+	return validator, nil
+}
+
 func (sp *StakingPool) SelectValidator() (string, error) {
 	activeValidators := sp.getActiveValidators()
 	if len(activeValidators) == 0 {
@@ -86,23 +216,25 @@ func (sp *StakingPool) SelectValidator() (string, error) {
 	}
 
 	// Weighted random selection based on stake amount
-	totalStake := 0.0
+	totalStake := new(big.Int)
 	for _, validator := range activeValidators {
-		totalStake += validator.StakedAmount
+		totalStake.Add(totalStake, new(big.Int).SetUint64(uint64(validator.StakedAmount)))
+	}
+	if totalStake.Sign() == 0 {
+		return "", fmt.Errorf("no stake available for selection")
 	}
 
 	// Generate random number between 0 and totalStake
-	randomBig, err := rand.Int(rand.Reader, big.NewInt(int64(totalStake*1000000)))
+	random, err := rand.Int(rand.Reader, totalStake)
 	if err != nil {
 		return "", err
 	}
-	random := float64(randomBig.Int64()) / 1000000.0
 
 	// Select validator based on weighted probability
-	currentWeight := 0.0
+	currentWeight := new(big.Int)
 	for address, validator := range activeValidators {
-		currentWeight += validator.StakedAmount
-		if random <= currentWeight {
+		currentWeight.Add(currentWeight, new(big.Int).SetUint64(uint64(validator.StakedAmount)))
+		if random.Cmp(currentWeight) < 0 {
 			return address, nil
 		}
 	}
@@ -134,26 +266,36 @@ func (sp *StakingPool) getActiveValidators() map[string]*Validator {
 	return active
 }
 
-func (sp *StakingPool) RewardValidator(address string, blockReward float64) error {
+// RewardValidator cộng thưởng cho validator tạo block có timestamp blockTime
+func (sp *StakingPool) RewardValidator(address string, blockReward coin.Amount, blockTime int64) error {
 	validator, exists := sp.Validators[address]
 	if !exists {
 		return fmt.Errorf("validator not found")
 	}
 
-	validator.TotalRewards += blockReward
-	validator.LastBlockTime = time.Now().Unix()
+	totalRewards, err := validator.TotalRewards.Add(blockReward)
+	if err != nil {
+		return err
+	}
+	validator.TotalRewards = totalRewards
+	validator.LastBlockTime = blockTime
 	return nil
 }
 
-func (sp *StakingPool) SlashValidator(address string) error {
+// SlashValidator phạt validator, có hiệu lực từ block có index height
+func (sp *StakingPool) SlashValidator(address string, height int64) error {
 	validator, exists := sp.Validators[address]
 	if !exists {
 		return fmt.Errorf("validator not found")
 	}
 
+	penalty, err := validator.StakedAmount.MulDiv(sp.SlashingPenalty, 100)
+	if err != nil {
+		return err
+	}
 	validator.SlashCount++
-	penalty := validator.StakedAmount * (sp.SlashingPenalty / 100.0)
 	validator.StakedAmount -= penalty
+	sp.recordEvent(StakeEventSlash, address, penalty, height)
 
 	// Deactivate if slashed too many times
 	if validator.SlashCount >= 3 {
@@ -186,17 +328,18 @@ func (sp *StakingPool) GetAllValidators() []*Validator {
 	return validators
 }
 
-func (sp *StakingPool) GetTotalStaked() float64 {
-	total := 0.0
+func (sp *StakingPool) GetTotalStaked() coin.Amount {
+	var total coin.Amount
 	for _, validator := range sp.Validators {
 		if validator.IsActive {
-			total += validator.StakedAmount
+			// Tổng stake luôn nhỏ hơn tổng cung nên không thể tràn số
+			total, _ = total.Add(validator.StakedAmount)
 		}
 	}
 	return total
 }
 
-func (sp *StakingPool) CalculateStakingRewards(address string) (float64, error) {
+func (sp *StakingPool) CalculateStakingRewards(address string) (coin.Amount, error) {
 	validator, exists := sp.Validators[address]
 	if !exists {
 		return 0, fmt.Errorf("validator not found")
@@ -209,8 +352,13 @@ func (sp *StakingPool) CalculateStakingRewards(address string) (float64, error)
 	// Calculate annual staking reward (5% per year)
 	// Simplified: assume 1 year = 365 * 24 * 60 * 60 seconds
 	stakingDuration := time.Now().Unix() - validator.JoinTime
-	annualReward := validator.StakedAmount * (sp.StakingReward / 100.0)
-	reward := annualReward * (float64(stakingDuration) / (365.0 * 24.0 * 60.0 * 60.0))
+	if stakingDuration <= 0 {
+		return 0, nil
+	}
+	annualReward, err := validator.StakedAmount.MulDiv(sp.StakingReward, 100)
+	if err != nil {
+		return 0, err
+	}
 
-	return reward, nil
+	return annualReward.MulDiv(uint64(stakingDuration), 365*24*60*60)
 }
@@ -0,0 +1,133 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Topic là nhóm sự kiện mà client có thể đăng ký
+type Topic string
+
+const (
+	// TopicBlock phát khi một block được nối vào chain (new) hoặc chain reorg sang nhánh
+	// nặng hơn (reorg, sau đó là new cho từng block của nhánh mới)
+	TopicBlock Topic = "block"
+	// TopicTransaction phát khi giao dịch vào pool, bị thay thế hoặc bị loại khỏi pool
+	TopicTransaction Topic = "transaction"
+	// TopicValidator phát khi validator stake, unstake hoặc bị slash
+	TopicValidator Topic = "validator"
+	// TopicBalance phát khi số dư đã xác nhận của một địa chỉ thay đổi
+	TopicBalance Topic = "balance"
+)
+
+// Topics là tất cả topic mà node phát
+var Topics = []Topic{TopicBlock, TopicTransaction, TopicValidator, TopicBalance}
+
+// Event là một sự kiện của node. Addresses là các địa chỉ liên quan, dùng để lọc
+// theo địa chỉ; Data là nội dung của sự kiện (được mã hóa JSON khi gửi cho client).
+type Event struct {
+	Topic     Topic       `json:"topic"`
+	Type      string      `json:"type"`
+	Addresses []string    `json:"addresses,omitempty"`
+	Data      interface{} `json:"data"`
+	Timestamp int64       `json:"timestamp"`
+}
+
+// Filter chọn sự kiện cho một subscription. Topics rỗng là mọi topic; Addresses rỗng là
+// mọi địa chỉ, ngược lại chỉ nhận sự kiện liên quan đến ít nhất một địa chỉ trong Addresses.
+type Filter struct {
+	Topics    map[Topic]bool
+	Addresses map[string]bool
+}
+
+func (f Filter) matches(event *Event) bool {
+	if len(f.Topics) > 0 && !f.Topics[event.Topic] {
+		return false
+	}
+	if len(f.Addresses) == 0 {
+		return true
+	}
+	for _, address := range event.Addresses {
+		if f.Addresses[address] {
+			return true
+		}
+	}
+	return false
+}
+
+// Bus phát sự kiện cho các subscription. Publish không bao giờ chặn: subscription
+// không đọc kịp (hàng đợi đầy) bị hủy và kênh Events của nó bị đóng.
+// Bus nil bỏ qua mọi sự kiện.
+type Bus struct {
+	mutex         sync.Mutex
+	subscriptions map[*Subscription]bool
+}
+
+func NewBus() *Bus {
+	return &Bus{subscriptions: make(map[*Subscription]bool)}
+}
+
+// Subscription nhận các sự kiện thỏa filter qua Events cho đến khi Close được gọi
+// hoặc bị bus hủy vì không đọc kịp
+type Subscription struct {
+	Events <-chan Event
+
+	events chan Event
+	filter Filter
+	bus    *Bus
+}
+
+// Subscribe đăng ký nhận sự kiện thỏa filter, buffer là số sự kiện tối đa chờ được đọc
+func (b *Bus) Subscribe(filter Filter, buffer int) *Subscription {
+	events := make(chan Event, buffer)
+	subscription := &Subscription{Events: events, events: events, filter: filter, bus: b}
+
+	b.mutex.Lock()
+	b.subscriptions[subscription] = true
+	b.mutex.Unlock()
+	return subscription
+}
+
+// Close hủy subscription và đóng Events; gọi nhiều lần không lỗi
+func (s *Subscription) Close() {
+	s.bus.mutex.Lock()
+	defer s.bus.mutex.Unlock()
+
+	s.bus.remove(s)
+}
+
+func (b *Bus) remove(subscription *Subscription) {
+	if b.subscriptions[subscription] {
+		delete(b.subscriptions, subscription)
+		close(subscription.events)
+	}
+}
+
+// Publish gửi event cho mọi subscription phù hợp
+func (b *Bus) Publish(topic Topic, eventType string, addresses []string, data interface{}) {
+	if b == nil {
+		return
+	}
+
+	event := Event{
+		Topic:     topic,
+		Type:      eventType,
+		Addresses: addresses,
+		Data:      data,
+		Timestamp: time.Now().Unix(),
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for subscription := range b.subscriptions {
+		if !subscription.filter.matches(&event) {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			b.remove(subscription)
+		}
+	}
+}
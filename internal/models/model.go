@@ -1,33 +1,368 @@
 package models
 
-import "MyCoinApp/internal/pool"
+import (
+	"MyCoinApp/internal/coin"
+	"MyCoinApp/internal/consensus"
+	"MyCoinApp/internal/pool"
+)
 
+// ErrorResponse là body của mọi response lỗi của REST API
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// ImportWalletRequest nhập ví bằng public key (khóa riêng giữ ở client), hoặc bằng
+// private key / passphrase khi node bật ký phía server. Thứ tự ưu tiên như khai báo.
+type ImportWalletRequest struct {
+	PublicKey  string `json:"public_key,omitempty"`
+	PrivateKey string `json:"private_key,omitempty"`
+	Passphrase string `json:"passphrase,omitempty"`
+}
+
+// CreateWalletResponse trả về ví mới hoặc ví vừa nhập; PrivateKey rỗng khi nhập bằng public key
 type CreateWalletResponse struct {
 	Address    string `json:"address"`
 	PublicKey  string `json:"public_key"`
-	PrivateKey string `json:"private_key"`
+	PrivateKey string `json:"private_key,omitempty"`
 }
 
+// BalanceResponse trả về số dư đã xác nhận và số dư còn dùng được sau khi
+// trừ các giao dịch đang chờ trong pending pool
 type BalanceResponse struct {
-	Address string  `json:"address"`
-	Balance float64 `json:"balance"`
+	Address   string      `json:"address"`
+	Balance   coin.Amount `json:"balance"`
+	Available coin.Amount `json:"available"`
+}
+
+// NonceResponse trả về nonce mà giao dịch tiếp theo của ví phải dùng (tính cả pending)
+// và nonce chỉ tính các giao dịch đã được xác nhận
+type NonceResponse struct {
+	Address        string `json:"address"`
+	Nonce          uint64 `json:"nonce"`
+	ConfirmedNonce uint64 `json:"confirmed_nonce"`
+}
+
+// ValidatorsResponse là danh sách validator đang hoạt động
+type ValidatorsResponse struct {
+	Validators []*consensus.Validator `json:"validators"`
+	Count      int                    `json:"count"`
 }
 
+// TransactionWithBlock là một giao dịch trong lịch sử của địa chỉ. Giao dịch đang chờ
+// có Status = pending, BlockIndex = -1 và không có BlockHash, BlockTimestamp.
 type TransactionWithBlock struct {
 	*pool.Transaction
-	BlockIndex int64  `json:"block_index"`
-	BlockHash  string `json:"block_hash"`
+	BlockIndex     int64  `json:"block_index"`
+	BlockHash      string `json:"block_hash"`
+	BlockTimestamp int64  `json:"block_timestamp,omitempty"`
+	Status         string `json:"status"`
+	Confirmations  int64  `json:"confirmations"`
 }
 
+// TransactionHistoryResponse là một trang lịch sử; NextCursor rỗng khi đã hết
 type TransactionHistoryResponse struct {
 	Address      string                  `json:"address"`
 	Transactions []*TransactionWithBlock `json:"transactions"`
+	NextCursor   string                  `json:"next_cursor,omitempty"`
+}
+
+// HistoryRecord là một dòng của bản xuất lịch sử giao dịch của một địa chỉ. Received và
+// Sent là số coin địa chỉ nhận và gửi (không gồm phí), Balance là số dư sau giao dịch.
+type HistoryRecord struct {
+	Hash         string               `json:"hash"`
+	BlockIndex   int64                `json:"block_index"`
+	BlockHash    string               `json:"block_hash"`
+	Timestamp    int64                `json:"timestamp"`
+	Type         pool.TransactionType `json:"type"`
+	Counterparty string               `json:"counterparty"`
+	Received     coin.Amount          `json:"received"`
+	Sent         coin.Amount          `json:"sent"`
+	Fee          coin.Amount          `json:"fee"`
+	Balance      coin.Amount          `json:"balance"`
+}
+
+// Chiều giao dịch dùng trong TransactionHistoryQuery
+const (
+	DirectionIn  = "in"
+	DirectionOut = "out"
+)
+
+// TransactionHistoryQuery là bộ lọc và phân trang của lịch sử giao dịch. Trường nil/0
+// là không lọc; khoảng thời gian và khoảng block đều tính cả hai đầu.
+type TransactionHistoryQuery struct {
+	// Direction là in, out hoặc rỗng (cả hai)
+	Direction string
+	MinAmount *coin.Amount
+	MaxAmount *coin.Amount
+	// FromTime, ToTime là Unix timestamp của block (của giao dịch nếu đang chờ)
+	FromTime int64
+	ToTime   int64
+	// Lọc theo block loại bỏ các giao dịch đang chờ
+	FromBlock *int64
+	ToBlock   *int64
+	// Order là asc (cũ trước) hoặc desc
+	Order  string
+	Limit  int
+	Cursor string
+	// IncludePending thêm các giao dịch đang chờ (sau các giao dịch đã xác nhận)
+	IncludePending bool
+}
+
+// TransactionSubmitResponse là kết quả gửi, thay thế hoặc hủy giao dịch;
+// ReplacedHash chỉ có khi một giao dịch đang chờ bị thay thế
+type TransactionSubmitResponse struct {
+	Status          string `json:"status"`
+	TransactionHash string `json:"transaction_hash"`
+	ReplacedHash    string `json:"replaced_hash,omitempty"`
+	Message         string `json:"message"`
 }
 
 type SendTransactionRequest struct {
-	From       string  `json:"from"`
-	To         string  `json:"to"`
-	Amount     float64 `json:"amount"`
-	Fee        float64 `json:"fee"`
-	PrivateKey string  `json:"private_key"`
+	From       string      `json:"from"`
+	To         string      `json:"to"`
+	Amount     coin.Amount `json:"amount"`
+	Fee        coin.Amount `json:"fee"`
+	PrivateKey string      `json:"private_key"`
+	// ValidUntilHeight là index block cuối cùng được chứa giao dịch, 0 là không hết hạn
+	ValidUntilHeight int64 `json:"valid_until_height,omitempty"`
+}
+
+// CancelTransactionRequest hủy giao dịch đang chờ có nonce Nonce của From;
+// Fee phải cao hơn phí của giao dịch bị hủy
+type CancelTransactionRequest struct {
+	From       string      `json:"from"`
+	Nonce      uint64      `json:"nonce"`
+	Fee        coin.Amount `json:"fee"`
+	PrivateKey string      `json:"private_key"`
+}
+
+// Trạng thái của giao dịch trong TransactionStatusResponse
+const (
+	TxStatusPending   = "pending"
+	TxStatusConfirmed = "confirmed"
+	// TxStatusDropped là giao dịch đã vào pool nhưng bị loại (hết hạn, bị thay thế, pool đầy...)
+	TxStatusDropped = "dropped"
+	// TxStatusRejected là giao dịch bị node từ chối khi gửi lên
+	TxStatusRejected = "rejected"
+)
+
+// TransactionStatusResponse là trạng thái của một giao dịch theo hash. Receipt chỉ có
+// khi giao dịch đã xác nhận, Reason chỉ có khi giao dịch bị loại hoặc bị từ chối.
+type TransactionStatusResponse struct {
+	Hash        string              `json:"hash"`
+	Status      string              `json:"status"`
+	Transaction *pool.Transaction   `json:"transaction,omitempty"`
+	Receipt     *TransactionReceipt `json:"receipt,omitempty"`
+	Reason      string              `json:"reason,omitempty"`
+}
+
+// TransactionReceipt là vị trí của giao dịch đã xác nhận; Confirmations tính cả block chứa giao dịch
+type TransactionReceipt struct {
+	BlockIndex    int64  `json:"block_index"`
+	BlockHash     string `json:"block_hash"`
+	Position      int    `json:"position"`
+	Confirmations int64  `json:"confirmations"`
+}
+
+// BlockchainInfoResponse là thông tin tổng quan của chain
+type BlockchainInfoResponse struct {
+	ChainLength         int64           `json:"chain_length"`
+	PendingTransactions int             `json:"pending_transactions"`
+	MiningReward        coin.Amount     `json:"mining_reward"`
+	IsValid             bool            `json:"is_valid"`
+	LatestBlock         *BlockResponse  `json:"latest_block"`
+	Sync                *SyncStatus     `json:"sync"`
+	ForkChoice          *ForkChoiceInfo `json:"fork_choice"`
+}
+
+// ForkChoiceInfo là trạng thái fork choice. Chain chính là nhánh có ChainWeight (tổng stake
+// của validator tạo từng block) lớn nhất. Block có index là bội của CheckpointInterval và có
+// ít nhất FinalityDepth block phía sau là checkpoint final; chain không reorg qua
+// FinalizedIndex. SideBlocks là số block hợp lệ trên các nhánh phụ.
+type ForkChoiceInfo struct {
+	ChainWeight        coin.Amount `json:"chain_weight"`
+	FinalizedIndex     int64       `json:"finalized_index"`
+	FinalizedHash      string      `json:"finalized_hash"`
+	CheckpointInterval int64       `json:"checkpoint_interval"`
+	FinalityDepth      int64       `json:"finality_depth"`
+	SideBlocks         int         `json:"side_blocks"`
+}
+
+// ReorgEvent là dữ liệu của sự kiện block "reorg": chain chính chuyển từ OldTip sang nhánh
+// nặng hơn kết thúc ở NewTip. Removed và Added là hash các block bị gỡ và được nối sau
+// CommonAncestor (theo thứ tự chain); ReturnedTransactions là các giao dịch của block bị
+// gỡ được trả lại pool.
+type ReorgEvent struct {
+	OldTip               string      `json:"old_tip"`
+	NewTip               string      `json:"new_tip"`
+	CommonAncestor       string      `json:"common_ancestor"`
+	AncestorIndex        int64       `json:"ancestor_index"`
+	Removed              []string    `json:"removed"`
+	Added                []string    `json:"added"`
+	OldWeight            coin.Amount `json:"old_weight"`
+	NewWeight            coin.Amount `json:"new_weight"`
+	ReturnedTransactions []string    `json:"returned_transactions"`
+}
+
+// SyncStatus là tiến độ đồng bộ chain từ các peer P2P. TargetHeight là số block của peer
+// cao nhất; Syncing là true từ khi node tụt lại đến khi theo kịp, khi đó Progress (0..1)
+// tính từ StartingHeight. Peer là node ID của peer đang cung cấp block.
+type SyncStatus struct {
+	Syncing        bool    `json:"syncing"`
+	CurrentHeight  int64   `json:"current_height"`
+	TargetHeight   int64   `json:"target_height"`
+	StartingHeight int64   `json:"starting_height,omitempty"`
+	Progress       float64 `json:"progress"`
+	Peer           string  `json:"peer,omitempty"`
+	StartedAt      int64   `json:"started_at,omitempty"`
+}
+
+// MineBlockRequest tạo block bằng private key của validator được chọn (ký phía server)
+type MineBlockRequest struct {
+	MinerAddress string `json:"miner_address"`
+	PrivateKey   string `json:"private_key"`
+}
+
+// BlockTemplateRequest xin block chưa ký cho validator MinerAddress
+type BlockTemplateRequest struct {
+	MinerAddress string `json:"miner_address"`
+}
+
+// MineBlockResponse là block vừa tạo, vừa nhận, hoặc block mẫu chờ validator ký BlockHash
+type MineBlockResponse struct {
+	Status    string         `json:"status"`
+	BlockHash string         `json:"block_hash"`
+	Block     *BlockResponse `json:"block"`
+}
+
+// MerkleProofStep là một nút anh em trên đường từ giao dịch lên Merkle root.
+// Position cho biết nút anh em nằm bên trái ("left") hay bên phải ("right").
+type MerkleProofStep struct {
+	Hash     string `json:"hash"`
+	Position string `json:"position"`
+}
+
+// MerkleProof chứng minh giao dịch TxHash nằm trong block BlockIndex. BlockVersion cho
+// biết cách tính Merkle tree của block (lá và nút trong có tiền tố riêng từ phiên bản 2).
+type MerkleProof struct {
+	BlockVersion uint8              `json:"block_version"`
+	TxHash       string             `json:"tx_hash"`
+	BlockIndex   int64              `json:"block_index"`
+	BlockHash    string             `json:"block_hash"`
+	MerkleRoot   string             `json:"merkle_root"`
+	Proof        []*MerkleProofStep `json:"proof"`
+}
+
+// MerkleProofResponse là Merkle proof của một giao dịch. Client tự kiểm tra proof với
+// Merkle root trong header block mà client đã tin cậy.
+type MerkleProofResponse struct {
+	Proof *MerkleProof `json:"proof"`
+}
+
+// ValidationResult là kết quả kiểm tra toàn bộ chain từ genesis
+type ValidationResult struct {
+	Valid         bool  `json:"valid"`
+	CheckedBlocks int64 `json:"checked_blocks"`
+	// InvalidBlock là index của block lỗi đầu tiên, -1 nếu chain hợp lệ
+	InvalidBlock int64  `json:"invalid_block"`
+	InvalidHash  string `json:"invalid_hash,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+// StakeRequest stake Amount coin của Address (ký phía server)
+type StakeRequest struct {
+	Address    string      `json:"address"`
+	Amount     coin.Amount `json:"amount"`
+	PrivateKey string      `json:"private_key"`
+}
+
+// UnstakeRequest rút toàn bộ stake của Address (ký phía server)
+type UnstakeRequest struct {
+	Address    string `json:"address"`
+	PrivateKey string `json:"private_key"`
+}
+
+// StakeResponse là kết quả gửi giao dịch stake hoặc unstake; Amount không có khi
+// unstake bằng giao dịch đã ký sẵn
+type StakeResponse struct {
+	Status          string      `json:"status"`
+	Message         string      `json:"message"`
+	Address         string      `json:"address"`
+	Amount          coin.Amount `json:"amount,omitempty"`
+	TransactionHash string      `json:"transaction_hash"`
+}
+
+// StakingInfoResponse là các tham số và tổng stake của staking pool
+type StakingInfoResponse struct {
+	TotalStaked      coin.Amount `json:"total_staked"`
+	MinStakeAmount   coin.Amount `json:"min_stake_amount"`
+	MaxValidators    int         `json:"max_validators"`
+	ActiveValidators int         `json:"active_validators"`
+	BlockReward      coin.Amount `json:"block_reward"`
+	// StakingReward và SlashingPenalty tính theo phần trăm
+	StakingReward   uint64              `json:"staking_reward"`
+	SlashingPenalty uint64              `json:"slashing_penalty"`
+	FeePolicy       consensus.FeePolicy `json:"fee_policy"`
+}
+
+// BlockListQuery là phân trang của danh sách block; From nil là bắt đầu từ đầu chain
+// theo thứ tự Order
+type BlockListQuery struct {
+	From  *int64
+	Limit int
+	Order string
+}
+
+// Thứ tự sắp xếp của BlockListResponse
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// BlockResponse là header, giao dịch và thống kê của một block. TotalFees là tổng phí
+// của các giao dịch người dùng, Size là kích thước (byte) của bản mã hóa nhị phân đầy đủ.
+type BlockResponse struct {
+	Version            uint8               `json:"version"`
+	Index              int64               `json:"index"`
+	Timestamp          int64               `json:"timestamp"`
+	Hash               string              `json:"hash"`
+	PreviousHash       string              `json:"previous_hash"`
+	MerkleRoot         string              `json:"merkle_root"`
+	Validator          string              `json:"validator"`
+	ValidatorPublicKey string              `json:"validator_public_key"`
+	Signature          string              `json:"signature"`
+	TransactionCount   int                 `json:"transaction_count"`
+	TotalFees          coin.Amount         `json:"total_fees"`
+	Size               int                 `json:"size"`
+	Confirmations      int64               `json:"confirmations"`
+	Transactions       []*pool.Transaction `json:"transactions"`
+}
+
+// BlockListResponse là một trang block; Next là from của trang tiếp theo (nếu còn)
+type BlockListResponse struct {
+	Blocks []*BlockResponse `json:"blocks"`
+	Height int64            `json:"height"`
+	From   int64            `json:"from"`
+	Limit  int              `json:"limit"`
+	Order  string           `json:"order"`
+	Next   *int64           `json:"next,omitempty"`
+}
+
+// PeerInfo là một peer P2P đang kết nối. Address là địa chỉ P2P gọi lại được của peer,
+// Height là số block peer có theo hello và các block peer gửi, Score là điểm phạt.
+type PeerInfo struct {
+	ID          string `json:"id"`
+	Address     string `json:"address"`
+	Inbound     bool   `json:"inbound"`
+	Height      int64  `json:"height"`
+	Score       int    `json:"score"`
+	ConnectedAt int64  `json:"connected_at"`
+}
+
+// PeersResponse là node ID, chain ID của node và các peer đang kết nối
+type PeersResponse struct {
+	NodeID  string      `json:"node_id"`
+	ChainID string      `json:"chain_id"`
+	Peers   []*PeerInfo `json:"peers"`
+	Count   int         `json:"count"`
 }
@@ -0,0 +1,205 @@
+package p2p
+
+import (
+	"MyCoinApp/internal/codec"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// ProtocolVersion là phiên bản giao thức P2P; node chỉ kết nối với peer cùng phiên bản.
+// Phiên bản 2 thêm các message đồng bộ chain (getHeaders, headers, getBlocks, blocks).
+// Phiên bản 3: block mới dùng BlockVersion2 mà node cũ không giải mã được.
+const ProtocolVersion uint8 = 3
+
+// Loại message. Mỗi message trên kết nối TCP là một frame: độ dài (uint32 big-endian,
+// tính cả byte loại), một byte loại và payload mã hóa bằng codec.
+const (
+	msgHello       uint8 = 1
+	msgPing        uint8 = 2
+	msgPong        uint8 = 3
+	msgTransaction uint8 = 4
+	msgBlock       uint8 = 5
+	msgGetHeaders  uint8 = 6
+	msgHeaders     uint8 = 7
+	msgGetBlocks   uint8 = 8
+	msgBlocks      uint8 = 9
+)
+
+// maxMessageSize giới hạn kích thước một frame để peer không làm node cấp phát quá nhiều bộ nhớ
+const maxMessageSize = codec.MaxFieldSize + 1024
+
+type message struct {
+	kind    uint8
+	payload []byte
+}
+
+func writeMessage(w io.Writer, msg message) error {
+	frame := make([]byte, 5+len(msg.payload))
+	binary.BigEndian.PutUint32(frame, uint32(1+len(msg.payload)))
+	frame[4] = msg.kind
+	copy(frame[5:], msg.payload)
+	_, err := w.Write(frame)
+	return err
+}
+
+func readMessage(r io.Reader) (message, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return message{}, err
+	}
+	length := binary.BigEndian.Uint32(header[:])
+	if length == 0 || length > maxMessageSize {
+		return message{}, fmt.Errorf("invalid message length %d", length)
+	}
+
+	frame := make([]byte, length)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		return message{}, err
+	}
+	return message{kind: frame[0], payload: frame[1:]}, nil
+}
+
+// hello là message đầu tiên mỗi bên gửi khi kết nối. Peer khác ProtocolVersion,
+// ChainID hoặc GenesisHash bị ngắt kết nối.
+type hello struct {
+	Version     uint8
+	ChainID     string
+	GenesisHash string
+	Height      int64
+	// NodeID là định danh ngẫu nhiên của node, dùng để phát hiện kết nối tới chính mình
+	// và kết nối trùng
+	NodeID string
+	// ListenPort là cổng P2P của peer, ghép với IP của kết nối để biết địa chỉ gọi lại peer
+	ListenPort string
+}
+
+func (h *hello) encode() []byte {
+	w := codec.NewWriter()
+	w.WriteUint8(h.Version)
+	w.WriteString(h.ChainID)
+	w.WriteString(h.GenesisHash)
+	w.WriteInt64(h.Height)
+	w.WriteString(h.NodeID)
+	w.WriteString(h.ListenPort)
+	return w.Bytes()
+}
+
+func decodeHello(data []byte) (*hello, error) {
+	r := codec.NewReader(data)
+	version := r.ReadUint8("version")
+	if r.Err() == nil && version != ProtocolVersion {
+		// Phiên bản khác có thể có bố cục khác nên không đọc tiếp
+		return nil, fmt.Errorf("protocol version %d is not supported, expected %d", version, ProtocolVersion)
+	}
+	h := &hello{
+		Version:     version,
+		ChainID:     r.ReadString("chain_id"),
+		GenesisHash: r.ReadString("genesis_hash"),
+		Height:      r.ReadInt64("height"),
+		NodeID:      r.ReadString("node_id"),
+		ListenPort:  r.ReadString("listen_port"),
+	}
+	if err := r.Finish(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// getHeaders yêu cầu tối đa Limit header nối tiếp hash đầu tiên của Locator có trong
+// chain của peer (xem Blockchain.Locator)
+type getHeaders struct {
+	Locator []string
+	Limit   uint64
+}
+
+func (m *getHeaders) encode() []byte {
+	w := codec.NewWriter()
+	writeStrings(w, m.Locator)
+	w.WriteUvarint(m.Limit)
+	return w.Bytes()
+}
+
+func decodeGetHeaders(data []byte) (*getHeaders, error) {
+	r := codec.NewReader(data)
+	locator, err := readStrings(r, "locator", maxLocatorSize)
+	if err != nil {
+		return nil, err
+	}
+	m := &getHeaders{Locator: locator, Limit: r.ReadUvarint("limit")}
+	if err := r.Finish(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// encodeItems mã hóa danh sách bản mã hóa header hoặc block (message headers, blocks)
+func encodeItems(items [][]byte) []byte {
+	w := codec.NewWriter()
+	w.WriteUvarint(uint64(len(items)))
+	for _, item := range items {
+		w.WriteBytes(item)
+	}
+	return w.Bytes()
+}
+
+func decodeItems(data []byte, field string, limit int) ([][]byte, error) {
+	r := codec.NewReader(data)
+	count := r.ReadUvarint(field + " count")
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	if count > uint64(limit) {
+		return nil, fmt.Errorf("too many %s (%d)", field, count)
+	}
+	items := make([][]byte, 0, count)
+	for i := uint64(0); i < count && r.Err() == nil; i++ {
+		items = append(items, r.ReadBytes(field))
+	}
+	if err := r.Finish(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// encodeHashes mã hóa danh sách hash block của message getBlocks
+func encodeHashes(hashes []string) []byte {
+	w := codec.NewWriter()
+	writeStrings(w, hashes)
+	return w.Bytes()
+}
+
+func decodeHashes(data []byte) ([]string, error) {
+	r := codec.NewReader(data)
+	hashes, err := readStrings(r, "block hash", maxBlocksPerRequest)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Finish(); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+func writeStrings(w *codec.Writer, values []string) {
+	w.WriteUvarint(uint64(len(values)))
+	for _, value := range values {
+		w.WriteString(value)
+	}
+}
+
+// readStrings đọc danh sách chuỗi có tối đa limit phần tử
+func readStrings(r *codec.Reader, field string, limit int) ([]string, error) {
+	count := r.ReadUvarint(field + " count")
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	if count > uint64(limit) {
+		return nil, fmt.Errorf("too many %s entries (%d)", field, count)
+	}
+	values := make([]string, 0, count)
+	for i := uint64(0); i < count && r.Err() == nil; i++ {
+		values = append(values, r.ReadString(field))
+	}
+	return values, r.Err()
+}
@@ -0,0 +1,576 @@
+// Package p2p kết nối các node MyCoin qua TCP: handshake kiểm tra cùng chain, lan truyền
+// (gossip) giao dịch và block mới, chấm điểm và cấm các peer gửi dữ liệu không hợp lệ.
+package p2p
+
+import (
+	"MyCoinApp/config"
+	"MyCoinApp/internal/blockchain"
+	"MyCoinApp/internal/events"
+	"MyCoinApp/internal/models"
+	"MyCoinApp/internal/pool"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// handshakeTimeout là thời gian tối đa để trao đổi hello sau khi kết nối
+	handshakeTimeout = 10 * time.Second
+	// pingInterval là chu kỳ gửi ping; peer im lặng quá idleTimeout bị ngắt
+	pingInterval = 30 * time.Second
+	idleTimeout  = 3 * pingInterval
+	// redialInterval là chu kỳ kết nối lại các peer tĩnh đã mất kết nối
+	redialInterval = 10 * time.Second
+	// gossipBuffer là số sự kiện tối đa chờ lan truyền
+	gossipBuffer = 1024
+)
+
+// Điểm phạt của peer; peer đạt banThreshold bị ngắt và bị cấm trong Config.PeerBanDuration
+const (
+	banThreshold = 100
+	// penaltyMalformed là message không giải mã được hoặc sai giao thức
+	penaltyMalformed = 50
+	// penaltyInvalid là giao dịch hoặc block sai hash, chữ ký hay luật đồng thuận
+	penaltyInvalid = 20
+	// penaltyOrphan là block không nối được vào chain do một khóa chưa từng stake ký
+	penaltyOrphan = 5
+)
+
+// Node là lớp P2P của một node: nhận kết nối trên Config.P2PPort, giữ kết nối tới các
+// peer tĩnh trong Config.Peers và lan truyền giao dịch, block mới lấy từ event bus
+type Node struct {
+	blockchain  *blockchain.Blockchain
+	events      *events.Bus
+	chainID     string
+	listenAddr  string
+	staticPeers []string
+	maxPeers    int
+	banDuration time.Duration
+	nodeID      string
+
+	mutex sync.Mutex
+	// peers là các peer đã qua handshake theo node ID
+	peers map[string]*Peer
+	// handshaking là số kết nối đang handshake, được tính vào maxPeers cùng peers
+	handshaking int
+	// bans là thời điểm hết cấm theo IP của peer. Node ID và cổng do peer tự khai trong
+	// hello nên không dùng để cấm.
+	bans map[string]time.Time
+	// dialed là node ID của peer tĩnh theo địa chỉ đã kết nối thành công
+	dialed map[string]string
+
+	// chainSync là phiên đồng bộ chain từ peer (xem sync.go)
+	syncMutex   sync.Mutex
+	chainSync   syncState
+	syncTrigger chan struct{}
+}
+
+func NewNode(bc *blockchain.Blockchain, bus *events.Bus, cfg *config.Config) *Node {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+
+	return &Node{
+		blockchain:  bc,
+		events:      bus,
+		chainID:     cfg.ChainID,
+		listenAddr:  cfg.P2PPort,
+		staticPeers: cfg.Peers,
+		maxPeers:    cfg.MaxPeers,
+		banDuration: cfg.PeerBanDuration,
+		nodeID:      hex.EncodeToString(id),
+		peers:       make(map[string]*Peer),
+		bans:        make(map[string]time.Time),
+		dialed:      make(map[string]string),
+		syncTrigger: make(chan struct{}, 1),
+	}
+}
+
+// Start mở cổng P2P rồi chạy nền việc nhận kết nối, kết nối peer tĩnh, lan truyền và
+// đồng bộ chain
+func (n *Node) Start() error {
+	listener, err := net.Listen("tcp", n.listenAddr)
+	if err != nil {
+		return err
+	}
+	log.Printf("P2P node %s listening on %s (chain %s)", n.nodeID, n.listenAddr, n.chainID)
+
+	go n.acceptLoop(listener)
+	go n.dialLoop()
+	go n.pingLoop()
+	go n.gossipLoop()
+	go n.syncLoop()
+	return nil
+}
+
+func (n *Node) acceptLoop(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Printf("P2P listener stopped: %v", err)
+			return
+		}
+		go n.connect(conn, "", true)
+	}
+}
+
+// remoteIP trả về IP của đầu bên kia kết nối, là khóa để cấm peer
+func remoteIP(conn net.Conn) string {
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return conn.RemoteAddr().String()
+	}
+	return host
+}
+
+// dialLoop kết nối tới các peer tĩnh chưa có kết nối, lặp lại sau mỗi redialInterval
+func (n *Node) dialLoop() {
+	ticker := time.NewTicker(redialInterval)
+	defer ticker.Stop()
+	for {
+		for _, address := range n.staticPeers {
+			if !n.hasFreeSlot() {
+				break
+			}
+			host, _, _ := net.SplitHostPort(address)
+			if n.isConnectedTo(address) || n.isBanned(host) {
+				continue
+			}
+			conn, err := net.DialTimeout("tcp", address, handshakeTimeout)
+			if err != nil {
+				log.Printf("P2P dial %s failed: %v", address, err)
+				continue
+			}
+			go n.connect(conn, address, false)
+		}
+		<-ticker.C
+	}
+}
+
+func (n *Node) isConnectedTo(address string) bool {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	id, exists := n.dialed[address]
+	return exists && n.peers[id] != nil
+}
+
+// connect thực hiện handshake trên conn rồi phục vụ peer cho đến khi mất kết nối.
+// dialAddress là địa chỉ đã gọi với kết nối đi, rỗng với kết nối đến. Kết nối từ IP bị
+// cấm hoặc khi đã đủ maxPeers bị đóng trước handshake.
+func (n *Node) connect(conn net.Conn, dialAddress string, inbound bool) {
+	if n.isBanned(remoteIP(conn)) {
+		log.Printf("P2P rejected connection with %s: banned", conn.RemoteAddr())
+		conn.Close()
+		return
+	}
+	if !n.reserveSlot() {
+		log.Printf("P2P rejected connection with %s: %d peers connected", conn.RemoteAddr(), n.maxPeers)
+		conn.Close()
+		return
+	}
+
+	peer, err := n.handshake(conn, dialAddress, inbound)
+	if err != nil {
+		n.releaseSlot()
+		log.Printf("P2P handshake with %s failed: %v", conn.RemoteAddr(), err)
+		conn.Close()
+		return
+	}
+	if !n.addPeer(peer, dialAddress) {
+		conn.Close()
+		return
+	}
+	log.Printf("P2P connected to peer %s at %s (height %d, inbound %t)", peer.id, peer.address, peer.height, inbound)
+	if peer.height > n.blockchain.Height() {
+		n.requestSync()
+	}
+
+	go peer.writeLoop()
+	n.readLoop(peer)
+
+	n.removePeer(peer)
+	peer.close()
+	log.Printf("P2P disconnected from peer %s at %s", peer.id, peer.address)
+}
+
+// handshake gửi hello của node và kiểm tra hello của peer: cùng phiên bản giao thức,
+// cùng chain ID và genesis block, không phải chính node
+func (n *Node) handshake(conn net.Conn, dialAddress string, inbound bool) (*Peer, error) {
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	_, listenPort, _ := net.SplitHostPort(n.listenAddr)
+	local := &hello{
+		Version:     ProtocolVersion,
+		ChainID:     n.chainID,
+		GenesisHash: n.blockchain.GenesisHash(),
+		Height:      n.blockchain.Height(),
+		NodeID:      n.nodeID,
+		ListenPort:  listenPort,
+	}
+	if err := writeMessage(conn, message{kind: msgHello, payload: local.encode()}); err != nil {
+		return nil, err
+	}
+
+	msg, err := readMessage(conn)
+	if err != nil {
+		return nil, err
+	}
+	if msg.kind != msgHello {
+		return nil, fmt.Errorf("expected hello, got message type %d", msg.kind)
+	}
+	remote, err := decodeHello(msg.payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if remote.ChainID != n.chainID {
+		return nil, fmt.Errorf("peer is on chain %q, expected %q", remote.ChainID, n.chainID)
+	}
+	if remote.GenesisHash != local.GenesisHash {
+		return nil, fmt.Errorf("peer has genesis block %s, expected %s", remote.GenesisHash, local.GenesisHash)
+	}
+	if remote.NodeID == n.nodeID {
+		return nil, fmt.Errorf("connected to self")
+	}
+
+	address := dialAddress
+	if inbound {
+		host, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+		address = net.JoinHostPort(host, remote.ListenPort)
+	}
+
+	return newPeer(conn, remote, address, inbound), nil
+}
+
+// hasFreeSlot cho biết số peer và kết nối đang handshake còn dưới maxPeers
+func (n *Node) hasFreeSlot() bool {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	return len(n.peers)+n.handshaking < n.maxPeers
+}
+
+// reserveSlot giữ chỗ cho một kết nối sắp handshake; trả về false nếu đã đủ maxPeers.
+// Chỗ được trả lại bằng releaseSlot hoặc chuyển thành peer trong addPeer.
+func (n *Node) reserveSlot() bool {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if len(n.peers)+n.handshaking >= n.maxPeers {
+		return false
+	}
+	n.handshaking++
+	return true
+}
+
+func (n *Node) releaseSlot() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.handshaking--
+}
+
+// addPeer đăng ký peer đã qua handshake và giải phóng chỗ giữ bởi reserveSlot. Khi hai
+// node kết nối tới nhau cùng lúc, cả hai bên cùng giữ kết nối do node có ID nhỏ hơn gọi đi.
+func (n *Node) addPeer(peer *Peer, dialAddress string) bool {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.handshaking--
+
+	if existing := n.peers[peer.id]; existing != nil {
+		dialer := n.nodeID
+		if peer.inbound {
+			dialer = peer.id
+		}
+		preferred := dialer == n.nodeID && n.nodeID < peer.id || dialer == peer.id && peer.id < n.nodeID
+		if !preferred {
+			if dialAddress != "" {
+				n.dialed[dialAddress] = peer.id
+			}
+			return false
+		}
+		existing.close()
+	}
+
+	n.peers[peer.id] = peer
+	if dialAddress != "" {
+		n.dialed[dialAddress] = peer.id
+	}
+	return true
+}
+
+func (n *Node) removePeer(peer *Peer) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.peers[peer.id] == peer {
+		delete(n.peers, peer.id)
+	}
+}
+
+func (n *Node) readLoop(peer *Peer) {
+	for {
+		peer.conn.SetReadDeadline(time.Now().Add(idleTimeout))
+		msg, err := readMessage(peer.conn)
+		if err != nil {
+			return
+		}
+		n.handle(peer, msg)
+
+		select {
+		case <-peer.closed:
+			return
+		default:
+		}
+	}
+}
+
+func (n *Node) handle(peer *Peer, msg message) {
+	switch msg.kind {
+	case msgPing:
+		peer.queue(message{kind: msgPong})
+	case msgPong:
+	case msgTransaction:
+		n.handleTransaction(peer, msg.payload)
+	case msgBlock:
+		n.handleBlock(peer, msg.payload)
+	case msgGetHeaders:
+		n.handleGetHeaders(peer, msg.payload)
+	case msgHeaders:
+		n.handleHeaders(peer, msg.payload)
+	case msgGetBlocks:
+		n.handleGetBlocks(peer, msg.payload)
+	case msgBlocks:
+		n.handleBlocks(peer, msg.payload)
+	default:
+		n.penalize(peer, penaltyMalformed, fmt.Sprintf("unexpected message type %d", msg.kind))
+	}
+}
+
+// handleTransaction đưa giao dịch peer gửi vào pool. Giao dịch sai chữ ký hoặc hash bị
+// phạt; giao dịch bị từ chối vì state (nonce đã dùng, pool đầy...) thì không, vì peer
+// trung thực cũng có thể gửi khi state của hai node lệch nhau.
+func (n *Node) handleTransaction(peer *Peer, payload []byte) {
+	tx, err := pool.DecodeTransaction(payload)
+	if err != nil {
+		n.penalize(peer, penaltyMalformed, fmt.Sprintf("malformed transaction: %v", err))
+		return
+	}
+	peer.markKnown(tx.Hash)
+
+	// Giao dịch bị từ chối hoặc bị loại trước đó vẫn được xét lại: số dư hay nonce có thể đã đổi
+	if status, known := n.blockchain.GetTransactionStatus(tx.Hash); known &&
+		(status.Status == models.TxStatusPending || status.Status == models.TxStatusConfirmed) {
+		return
+	}
+	if tx.IsSystem() || !tx.IsValid() {
+		n.penalize(peer, penaltyInvalid, fmt.Sprintf("invalid transaction %s", tx.Hash))
+		return
+	}
+	if err := tx.Verify(); err != nil {
+		n.penalize(peer, penaltyInvalid, fmt.Sprintf("transaction %s: %v", tx.Hash, err))
+		return
+	}
+
+	if _, pending := n.blockchain.TxPool.GetByNonce(tx.From, tx.Nonce); pending {
+		_, err = n.blockchain.ReplaceTransaction(tx)
+	} else {
+		err = n.blockchain.AddTransaction(tx)
+	}
+	if err != nil {
+		log.Printf("P2P transaction %s from peer %s rejected: %v", tx.Hash, peer.id, err)
+	}
+}
+
+// handleBlock đưa block peer gửi vào Blockchain.AddBlock: block nối tiếp chain được nối
+// vào, block của nhánh khác được lưu và có thể làm chain reorg. Block sai hash, Merkle root,
+// chữ ký hoặc luật đồng thuận bị phạt; block có block cha node chưa biết được tải qua đồng bộ.
+func (n *Node) handleBlock(peer *Peer, payload []byte) {
+	block, err := blockchain.DecodeBlock(payload)
+	if err != nil {
+		n.penalize(peer, penaltyMalformed, fmt.Sprintf("malformed block: %v", err))
+		return
+	}
+	peer.markKnown(block.Hash)
+
+	if n.blockchain.HasBlock(block.Hash) {
+		return
+	}
+	if block.Hash != block.CalculateHash() {
+		n.penalize(peer, penaltyInvalid, fmt.Sprintf("block %d has an invalid hash", block.Index))
+		return
+	}
+	if err := block.VerifyMerkleRoot(); err != nil {
+		n.penalize(peer, penaltyInvalid, fmt.Sprintf("block %s: %v", block.Hash, err))
+		return
+	}
+	if err := block.VerifySignature(); err != nil {
+		n.penalize(peer, penaltyInvalid, fmt.Sprintf("block %s: %v", block.Hash, err))
+		return
+	}
+
+	if !n.blockchain.HasBlock(block.PreviousHash) {
+		// Ai cũng ký được block với Index tùy ý bằng một khóa mới, nên block chưa nối được vào
+		// chain chỉ kích hoạt đồng bộ khi do một validator đã đăng ký tạo ra. Height của peer
+		// không lấy từ block này mà từ hello và các header nối được vào chain khi đồng bộ.
+		if _, err := n.blockchain.GetValidatorInfo(block.Validator); err != nil {
+			n.penalize(peer, penaltyOrphan, fmt.Sprintf("block %s with unknown parent from unregistered validator %s",
+				block.Hash, block.Validator))
+			return
+		}
+		// Node đang tụt lại hoặc chưa biết nhánh của peer: tải các block còn thiếu từ peer
+		peer.markMissing()
+		n.requestSync()
+		return
+	}
+
+	if err := n.blockchain.AddBlock(block); err != nil {
+		// Block ký trùng height do validator ký, peer chỉ chuyển tiếp nên không bị phạt
+		if errors.Is(err, blockchain.ErrBelowFinality) || errors.Is(err, blockchain.ErrUnknownParent) ||
+			errors.Is(err, blockchain.ErrTooManySideBlocks) || errors.Is(err, blockchain.ErrDoubleSigned) ||
+			n.blockchain.HasBlock(block.Hash) {
+			log.Printf("P2P block #%d %s from peer %s ignored: %v", block.Index, block.Hash, peer.id, err)
+			return
+		}
+		n.penalize(peer, penaltyInvalid, fmt.Sprintf("block %s rejected: %v", block.Hash, err))
+		return
+	}
+	// Block đã nối vào chain (hoặc một nhánh) và qua mọi luật đồng thuận: peer có ít nhất
+	// chừng ấy block
+	peer.updateHeight(block.Index + 1)
+	log.Printf("P2P accepted block #%d %s from peer %s", block.Index, block.Hash, peer.id)
+}
+
+// penalize cộng điểm phạt cho peer; peer đạt banThreshold bị ngắt kết nối và IP của peer bị cấm
+func (n *Node) penalize(peer *Peer, points int, reason string) {
+	score := peer.penalize(points)
+	log.Printf("P2P peer %s at %s penalized (%d points): %s", peer.id, peer.address, score, reason)
+	if score < banThreshold {
+		return
+	}
+
+	until := time.Now().Add(n.banDuration)
+	n.mutex.Lock()
+	n.bans[peer.ip] = until
+	n.mutex.Unlock()
+
+	log.Printf("P2P banned peer %s at %s (IP %s) until %s", peer.id, peer.address, peer.ip, until.Format(time.RFC3339))
+	peer.close()
+}
+
+func (n *Node) isBanned(key string) bool {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	until, exists := n.bans[key]
+	if !exists {
+		return false
+	}
+	if time.Now().After(until) {
+		delete(n.bans, key)
+		return false
+	}
+	return true
+}
+
+func (n *Node) pingLoop() {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		for _, peer := range n.connectedPeers() {
+			peer.queue(message{kind: msgPing})
+		}
+	}
+}
+
+func (n *Node) connectedPeers() []*Peer {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	peers := make([]*Peer, 0, len(n.peers))
+	for _, peer := range n.peers {
+		peers = append(peers, peer)
+	}
+	return peers
+}
+
+// gossipLoop gửi giao dịch vào pool và block mới nối vào chain (từ API, block producer
+// hay từ peer khác) cho các peer chưa biết
+func (n *Node) gossipLoop() {
+	filter := events.Filter{Topics: map[events.Topic]bool{events.TopicTransaction: true, events.TopicBlock: true}}
+	for {
+		subscription := n.events.Subscribe(filter, gossipBuffer)
+		for event := range subscription.Events {
+			n.gossip(event)
+		}
+		log.Printf("P2P gossip fell behind the event bus, resubscribing")
+	}
+}
+
+func (n *Node) gossip(event events.Event) {
+	switch data := event.Data.(type) {
+	case *models.TransactionStatusResponse:
+		// Sự kiện pending và replaced đều mang giao dịch đang chờ mới
+		if data.Status != models.TxStatusPending || data.Transaction == nil {
+			return
+		}
+		payload, err := data.Transaction.MarshalBinary()
+		if err != nil {
+			return
+		}
+		n.broadcast(data.Transaction.Hash, message{kind: msgTransaction, payload: payload})
+
+	case *models.BlockResponse:
+		block, exists := n.blockchain.BlockAt(data.Index)
+		if !exists || block.Hash != data.Hash {
+			return
+		}
+		payload, err := block.MarshalBinary()
+		if err != nil {
+			return
+		}
+		n.broadcast(block.Hash, message{kind: msgBlock, payload: payload})
+	}
+}
+
+// broadcast gửi msg cho các peer chưa biết hash; peer có hàng đợi đầy bị ngắt kết nối
+func (n *Node) broadcast(hash string, msg message) {
+	for _, peer := range n.connectedPeers() {
+		if !peer.markKnown(hash) {
+			continue
+		}
+		if !peer.queue(msg) {
+			log.Printf("P2P peer %s is not keeping up, disconnecting", peer.id)
+			peer.close()
+		}
+	}
+}
+
+// Peers trả về các peer đang kết nối, sắp theo địa chỉ
+func (n *Node) Peers() *models.PeersResponse {
+	peers := n.connectedPeers()
+	infos := make([]*models.PeerInfo, 0, len(peers))
+	for _, peer := range peers {
+		infos = append(infos, peer.info())
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Address < infos[j].Address
+	})
+
+	return &models.PeersResponse{
+		NodeID:  n.nodeID,
+		ChainID: n.chainID,
+		Peers:   infos,
+		Count:   len(infos),
+	}
+}
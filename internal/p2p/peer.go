@@ -0,0 +1,171 @@
+package p2p
+
+import (
+	"MyCoinApp/internal/models"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// sendQueueSize là số message tối đa chờ gửi cho một peer; peer không nhận kịp bị ngắt
+	sendQueueSize = 256
+	// maxKnownItems là số hash giao dịch/block gần nhất ghi nhớ cho mỗi peer để không gửi lại
+	maxKnownItems = 4096
+	// writeTimeout là thời gian tối đa để gửi một message
+	writeTimeout = 10 * time.Second
+)
+
+// Peer là một kết nối P2P đã qua handshake
+type Peer struct {
+	conn     net.Conn
+	id       string
+	address  string
+	ip       string // IP của kết nối, dùng để cấm peer
+	inbound  bool
+	joinedAt time.Time
+
+	send      chan message
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	mutex  sync.Mutex
+	height int64
+	// synced là height của peer khi node đồng bộ xong từ peer lần cuối; missing là peer đã
+	// gửi block mà node thiếu block cha (xem hasBlocksFor)
+	synced  int64
+	missing bool
+	score   int
+	known   map[string]bool
+	order   []string
+}
+
+func newPeer(conn net.Conn, info *hello, address string, inbound bool) *Peer {
+	return &Peer{
+		conn:     conn,
+		id:       info.NodeID,
+		address:  address,
+		ip:       remoteIP(conn),
+		inbound:  inbound,
+		joinedAt: time.Now(),
+		send:     make(chan message, sendQueueSize),
+		closed:   make(chan struct{}),
+		height:   info.Height,
+		known:    make(map[string]bool),
+	}
+}
+
+// queue đưa msg vào hàng đợi gửi; trả về false nếu hàng đợi đầy hoặc peer đã đóng
+func (p *Peer) queue(msg message) bool {
+	select {
+	case <-p.closed:
+		return false
+	default:
+	}
+
+	select {
+	case p.send <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *Peer) close() {
+	p.closeOnce.Do(func() {
+		close(p.closed)
+		p.conn.Close()
+	})
+}
+
+// writeLoop gửi các message trong hàng đợi cho đến khi peer đóng
+func (p *Peer) writeLoop() {
+	for {
+		select {
+		case <-p.closed:
+			return
+		case msg := <-p.send:
+			p.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := writeMessage(p.conn, msg); err != nil {
+				p.close()
+				return
+			}
+		}
+	}
+}
+
+// markKnown ghi nhớ peer đã có hash; trả về false nếu đã ghi nhớ từ trước
+func (p *Peer) markKnown(hash string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.known[hash] {
+		return false
+	}
+	if len(p.order) >= maxKnownItems {
+		delete(p.known, p.order[0])
+		p.order = p.order[1:]
+	}
+	p.known[hash] = true
+	p.order = append(p.order, hash)
+	return true
+}
+
+// updateHeight ghi nhận peer có ít nhất height block
+func (p *Peer) updateHeight(height int64) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if height > p.height {
+		p.height = height
+	}
+}
+
+// markMissing ghi nhận peer có block mà node thiếu block cha (peer ở nhánh khác hoặc cao hơn)
+func (p *Peer) markMissing() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.missing = true
+}
+
+// markSynced ghi nhận node đã có mọi block peer có thể gửi tính đến height hiện tại của peer
+func (p *Peer) markSynced() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.synced = p.height
+	p.missing = false
+}
+
+// hasBlocksFor cho biết peer có thể có block node chưa có: peer cao hơn height của node
+// và cao hơn lần đồng bộ cuối, hoặc peer đã gửi block node thiếu block cha
+func (p *Peer) hasBlocksFor(height int64) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.missing || p.height > height && p.height > p.synced
+}
+
+// penalize cộng điểm phạt và trả về tổng điểm
+func (p *Peer) penalize(points int) int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.score += points
+	return p.score
+}
+
+func (p *Peer) info() *models.PeerInfo {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return &models.PeerInfo{
+		ID:          p.id,
+		Address:     p.address,
+		Inbound:     p.inbound,
+		Height:      p.height,
+		Score:       p.score,
+		ConnectedAt: p.joinedAt.Unix(),
+	}
+}
@@ -0,0 +1,397 @@
+package p2p
+
+import (
+	"MyCoinApp/internal/blockchain"
+	"MyCoinApp/internal/codec"
+	"MyCoinApp/internal/models"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Đồng bộ chain (initial block download): node tụt lại so với peer cao nhất lấy header
+// nối tiếp chain của mình bằng getHeaders, rồi tải block theo lô bằng getBlocks. Mỗi
+// block đi qua Blockchain.AddBlock như block nhận qua gossip và được lưu ngay vào
+// blockchain.json, nên node khởi động lại tiếp tục từ block cuối đã lưu.
+const (
+	// maxLocatorSize là số hash tối đa trong locator của getHeaders
+	maxLocatorSize = 64
+	// maxHeadersPerMessage là số header tối đa trong một message headers
+	maxHeadersPerMessage = 2000
+	// blocksPerRequest là số block yêu cầu trong một getBlocks, tối đa maxBlocksPerRequest
+	blocksPerRequest    = 32
+	maxBlocksPerRequest = 128
+	// blocksPayloadLimit giới hạn kích thước một message blocks; message luôn có ít nhất một
+	// block, phần còn lại được yêu cầu lại
+	blocksPayloadLimit = codec.MaxFieldSize / 2
+	// syncInterval là chu kỳ kiểm tra node có tụt lại so với peer không
+	syncInterval = 5 * time.Second
+	// syncTimeout là thời gian chờ peer trả lời một yêu cầu đồng bộ
+	syncTimeout = 30 * time.Second
+	// syncRetryDelay là thời gian chờ trước khi thử lại sau khi phiên đồng bộ không tiến triển
+	syncRetryDelay = 30 * time.Second
+)
+
+// syncState là phiên đồng bộ hiện tại, được bảo vệ bởi Node.syncMutex
+type syncState struct {
+	// peer là peer đang cung cấp chain, nil khi không có phiên nào
+	peer *Peer
+	// awaiting là loại message đang chờ từ peer (msgHeaders hoặc msgBlocks)
+	awaiting uint8
+	// headers là hash các block đã nhận header nhưng chưa yêu cầu tải
+	headers []string
+	// requested là hash các block đã yêu cầu, theo thứ tự chain
+	requested []string
+	deadline  time.Time
+	retryAt   time.Time
+	// startHeight, startedAt là height và thời điểm node bắt đầu tụt lại; startedAt bằng
+	// zero khi node đã theo kịp các peer
+	startHeight int64
+	startedAt   time.Time
+}
+
+// requestSync yêu cầu syncLoop kiểm tra ngay thay vì chờ chu kỳ tiếp theo
+func (n *Node) requestSync() {
+	select {
+	case n.syncTrigger <- struct{}{}:
+	default:
+	}
+}
+
+func (n *Node) syncLoop() {
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-n.syncTrigger:
+		}
+		n.checkSync()
+	}
+}
+
+// checkSync theo dõi phiên đồng bộ đang chạy, hoặc bắt đầu phiên mới với peer cao nhất
+// trong các peer có thể có block node chưa có (xem Peer.hasBlocksFor)
+func (n *Node) checkSync() {
+	n.syncMutex.Lock()
+	defer n.syncMutex.Unlock()
+
+	state := &n.chainSync
+	if state.peer != nil {
+		select {
+		case <-state.peer.closed:
+			n.endSync(fmt.Sprintf("peer %s disconnected", state.peer.id))
+		default:
+			if time.Now().Before(state.deadline) {
+				return
+			}
+			// Peer không trả lời được coi là hỏng; dialLoop sẽ kết nối lại nếu là peer tĩnh
+			peer := state.peer
+			n.endSync(fmt.Sprintf("peer %s did not answer in %s", peer.id, syncTimeout))
+			peer.close()
+		}
+	}
+	if time.Now().Before(state.retryAt) {
+		return
+	}
+
+	height := n.blockchain.Height()
+	best, bestHeight := n.syncCandidate(height)
+	if best == nil {
+		n.finishSync()
+		return
+	}
+
+	if state.startedAt.IsZero() {
+		state.startedAt = time.Now()
+		state.startHeight = height
+		log.Printf("P2P sync started at height %d, peer %s has %d blocks", height, best.id, bestHeight)
+	}
+	state.peer = best
+	n.requestHeaders()
+}
+
+// syncCandidate trả về peer cao nhất có thể có block node chưa có, nil nếu không có
+func (n *Node) syncCandidate(height int64) (*Peer, int64) {
+	var best *Peer
+	var bestHeight int64 = -1
+	for _, peer := range n.connectedPeers() {
+		if peerHeight := peer.info().Height; peer.hasBlocksFor(height) && peerHeight > bestHeight {
+			best, bestHeight = peer, peerHeight
+		}
+	}
+	return best, bestHeight
+}
+
+// requestHeaders yêu cầu peer đồng bộ gửi header nối tiếp chain của node
+func (n *Node) requestHeaders() {
+	state := &n.chainSync
+	request := &getHeaders{Locator: n.blockchain.Locator(), Limit: maxHeadersPerMessage}
+	state.awaiting = msgHeaders
+	state.deadline = time.Now().Add(syncTimeout)
+	if !state.peer.queue(message{kind: msgGetHeaders, payload: request.encode()}) {
+		n.endSync(fmt.Sprintf("cannot send to peer %s", state.peer.id))
+	}
+}
+
+// requestBlocks yêu cầu lô block tiếp theo trong các header đã nhận
+func (n *Node) requestBlocks() {
+	state := &n.chainSync
+	if len(state.requested) == 0 {
+		count := min(blocksPerRequest, len(state.headers))
+		state.requested = state.headers[:count]
+		state.headers = state.headers[count:]
+	}
+	state.awaiting = msgBlocks
+	state.deadline = time.Now().Add(syncTimeout)
+	if !state.peer.queue(message{kind: msgGetBlocks, payload: encodeHashes(state.requested)}) {
+		n.endSync(fmt.Sprintf("cannot send to peer %s", state.peer.id))
+	}
+}
+
+// endSync kết thúc phiên đồng bộ không thành công; phiên mới được thử lại sau syncRetryDelay
+func (n *Node) endSync(reason string) {
+	log.Printf("P2P sync stopped at height %d: %s", n.blockchain.Height(), reason)
+	n.resetSync()
+	n.chainSync.retryAt = time.Now().Add(syncRetryDelay)
+}
+
+// finishSync kết thúc phiên khi peer không còn block nào để gửi
+func (n *Node) finishSync() {
+	state := &n.chainSync
+	n.resetSync()
+	if state.startedAt.IsZero() {
+		return
+	}
+
+	height := n.blockchain.Height()
+	if peer, _ := n.syncCandidate(height); peer != nil {
+		return
+	}
+	log.Printf("P2P sync completed at height %d (%d blocks in %s)",
+		height, height-state.startHeight, time.Since(state.startedAt).Round(time.Second))
+	state.startedAt = time.Time{}
+}
+
+func (n *Node) resetSync() {
+	state := &n.chainSync
+	state.peer = nil
+	state.awaiting = 0
+	state.headers = nil
+	state.requested = nil
+}
+
+// handleGetHeaders trả lời bằng header các block nối tiếp locator của peer
+func (n *Node) handleGetHeaders(peer *Peer, payload []byte) {
+	request, err := decodeGetHeaders(payload)
+	if err != nil {
+		n.penalize(peer, penaltyMalformed, fmt.Sprintf("malformed getHeaders: %v", err))
+		return
+	}
+	if request.Limit == 0 || request.Limit > maxHeadersPerMessage {
+		n.penalize(peer, penaltyMalformed, fmt.Sprintf("getHeaders limit %d is out of range", request.Limit))
+		return
+	}
+
+	blocks := n.blockchain.BlocksAfter(request.Locator, int(request.Limit))
+	headers := make([][]byte, 0, len(blocks))
+	for _, block := range blocks {
+		header, err := block.EncodeHeader()
+		if err != nil {
+			break
+		}
+		headers = append(headers, header)
+	}
+	peer.queue(message{kind: msgHeaders, payload: encodeItems(headers)})
+}
+
+// handleGetBlocks trả lời bằng các block được yêu cầu theo thứ tự, dừng ở block đầu tiên
+// không có trong chain hoặc khi message đạt blocksPayloadLimit
+func (n *Node) handleGetBlocks(peer *Peer, payload []byte) {
+	hashes, err := decodeHashes(payload)
+	if err != nil {
+		n.penalize(peer, penaltyMalformed, fmt.Sprintf("malformed getBlocks: %v", err))
+		return
+	}
+
+	var blocks [][]byte
+	size := 0
+	for _, hash := range hashes {
+		block, exists := n.blockchain.BlockByHash(hash)
+		if !exists {
+			break
+		}
+		data, err := block.MarshalBinary()
+		if err != nil {
+			break
+		}
+		if len(blocks) > 0 && size+len(data) > blocksPayloadLimit {
+			break
+		}
+		blocks = append(blocks, data)
+		size += len(data)
+	}
+	peer.queue(message{kind: msgBlocks, payload: encodeItems(blocks)})
+}
+
+// handleHeaders nhận header từ peer đồng bộ. Header phải nối tiếp nhau và nối vào một
+// block node đã có; header có thể thuộc nhánh khác với chain chính của node. Header rỗng
+// hoặc toàn block node đã có nghĩa là peer không còn block nào node chưa có.
+func (n *Node) handleHeaders(peer *Peer, payload []byte) {
+	items, err := decodeItems(payload, "header", maxHeadersPerMessage)
+	if err != nil {
+		n.penalize(peer, penaltyMalformed, fmt.Sprintf("malformed headers: %v", err))
+		return
+	}
+
+	n.syncMutex.Lock()
+	defer n.syncMutex.Unlock()
+
+	state := &n.chainSync
+	if state.peer != peer || state.awaiting != msgHeaders {
+		return
+	}
+	if len(items) == 0 {
+		peer.markSynced()
+		n.finishSync()
+		return
+	}
+
+	headers := make([]*blockchain.Block, 0, len(items))
+	var previous *blockchain.Block
+	for _, item := range items {
+		header, err := blockchain.DecodeHeader(item)
+		if err != nil {
+			n.penalize(peer, penaltyMalformed, fmt.Sprintf("malformed header: %v", err))
+			n.endSync(fmt.Sprintf("peer %s sent a malformed header", peer.id))
+			return
+		}
+		if previous == nil && !n.blockchain.HasBlock(header.PreviousHash) ||
+			previous != nil && (header.Index != previous.Index+1 || header.PreviousHash != previous.Hash) {
+			n.penalize(peer, penaltyInvalid, fmt.Sprintf("header #%d %s does not connect", header.Index, header.Hash))
+			n.endSync(fmt.Sprintf("peer %s sent disconnected headers", peer.id))
+			return
+		}
+		headers = append(headers, header)
+		previous = header
+	}
+	peer.updateHeight(previous.Index + 1)
+
+	// Bỏ các block node đã có: nhận qua gossip sau khi gửi getHeaders, hoặc nhánh của peer
+	// đã được tải trước đó nhưng nhẹ hơn chain chính
+	for len(headers) > 0 && n.blockchain.HasBlock(headers[0].Hash) {
+		headers = headers[1:]
+	}
+	if len(headers) == 0 {
+		if block, onChain := n.blockchain.BlockAt(previous.Index); onChain && block.Hash == previous.Hash {
+			n.requestHeaders()
+			return
+		}
+		peer.markSynced()
+		n.finishSync()
+		return
+	}
+
+	state.headers = make([]string, len(headers))
+	for i, header := range headers {
+		state.headers[i] = header.Hash
+	}
+	n.requestBlocks()
+}
+
+// handleBlocks đưa các block peer đồng bộ gửi vào Blockchain.ImportBlocks theo thứ tự đã
+// yêu cầu rồi yêu cầu lô tiếp theo; block của nhánh nặng hơn làm chain reorg. Cả lô chỉ
+// được lưu xuống file một lần. Block không được yêu cầu hoặc bị từ chối bị phạt.
+func (n *Node) handleBlocks(peer *Peer, payload []byte) {
+	items, err := decodeItems(payload, "block", maxBlocksPerRequest)
+	if err != nil {
+		n.penalize(peer, penaltyMalformed, fmt.Sprintf("malformed blocks: %v", err))
+		return
+	}
+
+	n.syncMutex.Lock()
+	defer n.syncMutex.Unlock()
+
+	state := &n.chainSync
+	if state.peer != peer || state.awaiting != msgBlocks {
+		return
+	}
+	if len(items) == 0 {
+		n.endSync(fmt.Sprintf("peer %s no longer has the requested blocks", peer.id))
+		return
+	}
+
+	blocks := make([]*blockchain.Block, 0, len(items))
+	for i, item := range items {
+		block, err := blockchain.DecodeBlock(item)
+		if err != nil {
+			n.penalize(peer, penaltyMalformed, fmt.Sprintf("malformed block: %v", err))
+			n.endSync(fmt.Sprintf("peer %s sent a malformed block", peer.id))
+			return
+		}
+		if i >= len(state.requested) || block.Hash != state.requested[i] {
+			n.penalize(peer, penaltyInvalid, fmt.Sprintf("unrequested block #%d %s", block.Index, block.Hash))
+			n.endSync(fmt.Sprintf("peer %s sent an unrequested block", peer.id))
+			return
+		}
+		peer.markKnown(block.Hash)
+		blocks = append(blocks, block)
+	}
+
+	if imported, err := n.blockchain.ImportBlocks(blocks); err != nil {
+		block := blocks[imported]
+		// Nhánh của peer có thể vừa bị bỏ khi checkpoint final tiến lên, node đã giữ đủ block
+		// nhánh phụ của validator hoặc validator đã ký block khác cùng height
+		if errors.Is(err, blockchain.ErrBelowFinality) || errors.Is(err, blockchain.ErrUnknownParent) ||
+			errors.Is(err, blockchain.ErrTooManySideBlocks) || errors.Is(err, blockchain.ErrDoubleSigned) {
+			n.endSync(fmt.Sprintf("block #%d %s from peer %s: %v", block.Index, block.Hash, peer.id, err))
+			return
+		}
+		n.penalize(peer, penaltyInvalid, fmt.Sprintf("block %s rejected: %v", block.Hash, err))
+		n.endSync(fmt.Sprintf("peer %s sent an invalid block", peer.id))
+		return
+	}
+	state.requested = state.requested[len(items):]
+	log.Printf("P2P synced to height %d from peer %s", n.blockchain.Height(), peer.id)
+
+	if len(state.requested) > 0 || len(state.headers) > 0 {
+		n.requestBlocks()
+		return
+	}
+	n.requestHeaders()
+}
+
+// SyncStatus trả về tiến độ đồng bộ chain. TargetHeight là height cao nhất trong các peer
+// có thể có block node chưa có; Progress tính từ height lúc node bắt đầu tụt lại.
+func (n *Node) SyncStatus() *models.SyncStatus {
+	n.syncMutex.Lock()
+	defer n.syncMutex.Unlock()
+
+	state := &n.chainSync
+	height := n.blockchain.Height()
+	status := &models.SyncStatus{
+		CurrentHeight: height,
+		TargetHeight:  height,
+		Progress:      1,
+	}
+	for _, peer := range n.connectedPeers() {
+		if peer.hasBlocksFor(height) {
+			status.TargetHeight = max(status.TargetHeight, peer.info().Height)
+		}
+	}
+	if state.peer != nil {
+		status.Peer = state.peer.id
+	}
+	if state.startedAt.IsZero() {
+		return status
+	}
+
+	status.Syncing = true
+	status.StartingHeight = state.startHeight
+	status.StartedAt = state.startedAt.Unix()
+	if total := status.TargetHeight - state.startHeight; total > 0 {
+		status.Progress = float64(height-state.startHeight) / float64(total)
+	}
+	return status
+}
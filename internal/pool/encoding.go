@@ -0,0 +1,184 @@
+package pool
+
+import (
+	"MyCoinApp/internal/codec"
+	"MyCoinApp/internal/coin"
+	"encoding/hex"
+	"fmt"
+)
+
+// TxVersion1 là định dạng mã hóa đầu tiên của giao dịch:
+//
+//	version u8 | type str | from str | to str | amount u64 | fee u64 | nonce u64 | timestamp i64
+//
+// Bản mã hóa đầy đủ (dùng để lưu trữ) thêm public_key bytes | signature bytes.
+// Trường mới phải được thêm trong phiên bản mới để hash của giao dịch cũ không đổi.
+const TxVersion1 uint8 = 1
+
+// TxVersion2 thêm cách chia phí của giao dịch thưởng sau các trường của TxVersion1:
+//
+//	has_fees u8 | collected u64 | validator u64 | burned u64 | treasury str | treasury_amount u64
+//
+// Các trường sau has_fees chỉ có khi has_fees = 1.
+const TxVersion2 uint8 = 2
+
+// TxVersion3 thêm hạn hiệu lực sau các trường của TxVersion2:
+//
+//	valid_until_height i64
+//
+// ValidUntilHeight = 0 nghĩa là giao dịch không hết hạn.
+const TxVersion3 uint8 = 3
+
+// MinEncodedSize là độ dài nhỏ nhất của bản mã hóa đầy đủ của một giao dịch: các trường
+// số cố định của TxVersion1 cùng một byte độ dài cho mỗi chuỗi và mỗi dãy byte
+const MinEncodedSize = 1 + 4*8 + 5
+
+// CurrentTxVersion là phiên bản dùng cho giao dịch mới tạo
+const CurrentTxVersion = TxVersion3
+
+// EncodeForHash trả về bản mã hóa chuẩn của các trường được hash và ký
+// (không gồm Hash, PublicKey và Signature)
+func (tx *Transaction) EncodeForHash() ([]byte, error) {
+	w := codec.NewWriter()
+	if err := tx.writeHashFields(w); err != nil {
+		return nil, err
+	}
+	return w.Bytes(), nil
+}
+
+func (tx *Transaction) writeHashFields(w *codec.Writer) error {
+	switch tx.Version {
+	case TxVersion1, TxVersion2, TxVersion3:
+	default:
+		return fmt.Errorf("unsupported transaction version %d", tx.Version)
+	}
+
+	w.WriteUint8(tx.Version)
+	w.WriteString(string(tx.Type))
+	w.WriteString(tx.From)
+	w.WriteString(tx.To)
+	w.WriteUint64(uint64(tx.Amount))
+	w.WriteUint64(uint64(tx.Fee))
+	w.WriteUint64(tx.Nonce)
+	w.WriteInt64(tx.Timestamp)
+
+	// Phiên bản cũ không có chỗ cho trường mới, không được bỏ qua dữ liệu khi hash
+	if tx.Version < TxVersion2 && tx.Fees != nil {
+		return fmt.Errorf("transaction version %d cannot carry fees", tx.Version)
+	}
+	if tx.Version < TxVersion3 && tx.ValidUntilHeight != 0 {
+		return fmt.Errorf("transaction version %d cannot carry valid until height", tx.Version)
+	}
+	if tx.Version == TxVersion1 {
+		return nil
+	}
+
+	if tx.Fees == nil {
+		w.WriteUint8(0)
+	} else {
+		w.WriteUint8(1)
+		w.WriteUint64(uint64(tx.Fees.Collected))
+		w.WriteUint64(uint64(tx.Fees.Validator))
+		w.WriteUint64(uint64(tx.Fees.Burned))
+		w.WriteString(tx.Fees.Treasury)
+		w.WriteUint64(uint64(tx.Fees.TreasuryAmount))
+	}
+
+	if tx.Version >= TxVersion3 {
+		w.WriteInt64(tx.ValidUntilHeight)
+	}
+	return nil
+}
+
+// MarshalBinary mã hóa đầy đủ giao dịch, gồm cả public key và chữ ký
+func (tx *Transaction) MarshalBinary() ([]byte, error) {
+	w := codec.NewWriter()
+	if err := tx.writeHashFields(w); err != nil {
+		return nil, err
+	}
+
+	publicKey, err := hex.DecodeString(tx.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("transaction %s: invalid public key encoding", tx.Hash)
+	}
+	signature, err := hex.DecodeString(tx.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("transaction %s: invalid signature encoding", tx.Hash)
+	}
+	w.WriteBytes(publicKey)
+	w.WriteBytes(signature)
+
+	return w.Bytes(), nil
+}
+
+// UnmarshalBinary giải mã giao dịch từ MarshalBinary và tính lại Hash
+func (tx *Transaction) UnmarshalBinary(data []byte) error {
+	r := codec.NewReader(data)
+	if err := tx.readFields(r); err != nil {
+		return err
+	}
+	if err := r.Finish(); err != nil {
+		return err
+	}
+
+	tx.Hash = tx.CalculateHash()
+	return nil
+}
+
+func (tx *Transaction) readFields(r *codec.Reader) error {
+	decoded := Transaction{Version: r.ReadUint8("transaction version")}
+	if err := r.Err(); err != nil {
+		return err
+	}
+
+	switch decoded.Version {
+	case TxVersion1, TxVersion2, TxVersion3:
+	default:
+		return fmt.Errorf("unsupported transaction version %d", decoded.Version)
+	}
+
+	decoded.Type = TransactionType(r.ReadString("transaction type"))
+	decoded.From = r.ReadString("transaction from")
+	decoded.To = r.ReadString("transaction to")
+	decoded.Amount = coin.Amount(r.ReadUint64("transaction amount"))
+	decoded.Fee = coin.Amount(r.ReadUint64("transaction fee"))
+	decoded.Nonce = r.ReadUint64("transaction nonce")
+	decoded.Timestamp = r.ReadInt64("transaction timestamp")
+
+	if decoded.Version >= TxVersion2 {
+		switch hasFees := r.ReadUint8("transaction has fees"); hasFees {
+		case 0:
+		case 1:
+			decoded.Fees = &FeeDistribution{
+				Collected:      coin.Amount(r.ReadUint64("fees collected")),
+				Validator:      coin.Amount(r.ReadUint64("fees validator")),
+				Burned:         coin.Amount(r.ReadUint64("fees burned")),
+				Treasury:       r.ReadString("fees treasury"),
+				TreasuryAmount: coin.Amount(r.ReadUint64("fees treasury amount")),
+			}
+		default:
+			return fmt.Errorf("decode transaction has fees: invalid flag %d", hasFees)
+		}
+	}
+	if decoded.Version >= TxVersion3 {
+		decoded.ValidUntilHeight = r.ReadInt64("transaction valid until height")
+	}
+
+	decoded.PublicKey = hex.EncodeToString(r.ReadBytes("transaction public key"))
+	decoded.Signature = hex.EncodeToString(r.ReadBytes("transaction signature"))
+
+	if err := r.Err(); err != nil {
+		return err
+	}
+	*tx = decoded
+	return nil
+}
+
+// DecodeTransaction giải mã một giao dịch từ bản mã hóa nhị phân đầy đủ
+func DecodeTransaction(data []byte) (*Transaction, error) {
+	var tx Transaction
+	if err := tx.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
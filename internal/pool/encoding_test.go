@@ -0,0 +1,136 @@
+package pool
+
+import (
+	"MyCoinApp/internal/coin"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testTransaction tạo giao dịch cố định của phiên bản version để hash của nó không đổi giữa các lần chạy
+func testTransaction(version uint8) *Transaction {
+	tx := &Transaction{
+		Version:   version,
+		Type:      TxTransfer,
+		From:      "00a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718",
+		To:        "00f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788",
+		Amount:    12*coin.Unit + 34,
+		Fee:       coin.Unit / 100,
+		Nonce:     7,
+		Timestamp: 1700000000,
+		PublicKey: strings.Repeat("ab", 64),
+		Signature: strings.Repeat("cd", 64),
+	}
+	if version >= TxVersion2 {
+		tx.Type = TxReward
+		tx.From = ""
+		tx.Fee = 0
+		tx.Nonce = 0
+		tx.PublicKey = ""
+		tx.Signature = ""
+		tx.Fees = &FeeDistribution{
+			Collected:      100,
+			Validator:      70,
+			Burned:         20,
+			Treasury:       "00c0ffee00c0ffee00c0ffee00c0ffee00c0ffee00c0ffee00",
+			TreasuryAmount: 10,
+		}
+	}
+	if version >= TxVersion3 {
+		tx.ValidUntilHeight = 42
+	}
+	tx.Hash = tx.CalculateHash()
+	return tx
+}
+
+// Hash của giao dịch đã ký không được đổi khi mã hóa thay đổi: các giá trị dưới đây là hash
+// của testTransaction ở từng phiên bản
+func TestTransactionHashStable(t *testing.T) {
+	tests := []struct {
+		version uint8
+		hash    string
+	}{
+		{TxVersion1, "c9e97c0c0600486fc5a71fd4c988b9978eee1557c6ab6e404aa168fbc51cf8cd"},
+		{TxVersion2, "f6dfb75c3714a8c307db24edaae8fbc0ac6f678ff5d6b4e3ceaed10517320152"},
+		{TxVersion3, "d96c6a248b4b86e17ee39b3de2a72606e75d65d14b18c27a0014ee9406c54d57"},
+	}
+
+	for _, tt := range tests {
+		if hash := testTransaction(tt.version).Hash; hash != tt.hash {
+			t.Errorf("version %d: hash = %s, want %s", tt.version, hash, tt.hash)
+		}
+	}
+}
+
+func TestTransactionRoundTrip(t *testing.T) {
+	for _, version := range []uint8{TxVersion1, TxVersion2, TxVersion3} {
+		tx := testTransaction(version)
+		data, err := tx.MarshalBinary()
+		if err != nil {
+			t.Fatalf("version %d: MarshalBinary: %v", version, err)
+		}
+
+		decoded, err := DecodeTransaction(data)
+		if err != nil {
+			t.Fatalf("version %d: DecodeTransaction: %v", version, err)
+		}
+		if !reflect.DeepEqual(decoded, tx) {
+			t.Errorf("version %d: decoded %+v, want %+v", version, decoded, tx)
+		}
+		if size := tx.Size(); size != len(data) {
+			t.Errorf("version %d: Size = %d, want %d", version, size, len(data))
+		}
+	}
+}
+
+func TestDecodeTransactionRejectsTruncatedData(t *testing.T) {
+	for _, version := range []uint8{TxVersion1, TxVersion2, TxVersion3} {
+		data, err := testTransaction(version).MarshalBinary()
+		if err != nil {
+			t.Fatalf("version %d: MarshalBinary: %v", version, err)
+		}
+		for n := 0; n < len(data); n++ {
+			if _, err := DecodeTransaction(data[:n]); err == nil {
+				t.Errorf("version %d: decoded %d of %d bytes without error", version, n, len(data))
+			}
+		}
+	}
+}
+
+func TestDecodeTransactionRejectsTrailingBytes(t *testing.T) {
+	for _, version := range []uint8{TxVersion1, TxVersion2, TxVersion3} {
+		data, err := testTransaction(version).MarshalBinary()
+		if err != nil {
+			t.Fatalf("version %d: MarshalBinary: %v", version, err)
+		}
+		if _, err := DecodeTransaction(append(data, 0)); err == nil {
+			t.Errorf("version %d: decoded data with a trailing byte without error", version)
+		}
+	}
+}
+
+func TestDecodeTransactionRejectsUnknownVersion(t *testing.T) {
+	data, err := testTransaction(TxVersion3).MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+	data[0] = TxVersion3 + 1
+	if _, err := DecodeTransaction(data); err == nil {
+		t.Error("decoded a transaction with an unknown version without error")
+	}
+}
+
+// Phiên bản cũ không có chỗ cho trường mới nên không được mã hóa (bỏ qua trường khi hash)
+func TestEncodeRejectsFieldsOfNewerVersions(t *testing.T) {
+	withFees := testTransaction(TxVersion2)
+	withFees.Version = TxVersion1
+	if _, err := withFees.EncodeForHash(); err == nil {
+		t.Error("encoded a version 1 transaction with fees")
+	}
+
+	withExpiry := testTransaction(TxVersion3)
+	withExpiry.Version = TxVersion2
+	if _, err := withExpiry.EncodeForHash(); err == nil {
+		t.Error("encoded a version 2 transaction with a valid until height")
+	}
+}
@@ -1,46 +1,355 @@
 package pool
 
-import "fmt"
+import (
+	"MyCoinApp/internal/coin"
+	"container/heap"
+	"fmt"
+	"math/bits"
+	"sort"
+	"sync"
+	"time"
+)
 
+// TransactionPool là mempool của node: lưu các giao dịch đang chờ vào block,
+// đánh chỉ mục theo hash và theo người gửi (nonce). An toàn khi dùng đồng thời.
+// Giao dịch được kiểm tra chữ ký, nonce và số dư ở Blockchain trước khi thêm vào pool.
 type TransactionPool struct {
-	Transactions []*Transaction `json:"transactions"`
+	mutex    sync.RWMutex
+	byHash   map[string]*Transaction
+	bySender map[string]map[uint64]*Transaction
+	// entries là thông tin pool giữ kèm mỗi giao dịch theo hash
+	entries map[string]poolEntry
+
+	// maxSize là số giao dịch tối đa trong pool, maxPerSender là số giao dịch
+	// đang chờ tối đa của một người gửi
+	maxSize      int
+	maxPerSender int
+}
+
+// poolEntry là thông tin của một giao dịch trong pool: addedAt là thời điểm giao dịch được
+// nhận vào pool, dùng để tính tuổi khi dọn pool; size là kích thước bản mã hóa nhị phân,
+// tính một lần khi thêm để so sánh phí trên mỗi byte không phải mã hóa lại giao dịch
+type poolEntry struct {
+	addedAt time.Time
+	size    int
 }
 
-func NewTransactionPool() *TransactionPool {
+func NewTransactionPool(maxSize, maxPerSender int) *TransactionPool {
 	return &TransactionPool{
-		Transactions: make([]*Transaction, 0),
+		byHash:       make(map[string]*Transaction),
+		bySender:     make(map[string]map[uint64]*Transaction),
+		entries:      make(map[string]poolEntry),
+		maxSize:      maxSize,
+		maxPerSender: maxPerSender,
+	}
+}
+
+// AddTransaction thêm giao dịch vào pool. Khi pool đầy, giao dịch có phí trên mỗi byte
+// thấp nhất của người gửi khác bị loại nếu thấp hơn giao dịch mới; ngược lại giao dịch
+// mới bị từ chối.
+// Trả về giao dịch bị loại (nếu có).
+func (tp *TransactionPool) AddTransaction(tx *Transaction) (*Transaction, error) {
+	tp.mutex.Lock()
+	defer tp.mutex.Unlock()
+
+	if _, exists := tp.byHash[tx.Hash]; exists {
+		return nil, fmt.Errorf("transaction already exists in pool")
+	}
+
+	senderTxs := tp.bySender[tx.From]
+	if _, exists := senderTxs[tx.Nonce]; exists {
+		return nil, fmt.Errorf("a transaction with nonce %d from %s is already pending", tx.Nonce, tx.From)
+	}
+	if tp.maxPerSender > 0 && len(senderTxs) >= tp.maxPerSender {
+		return nil, fmt.Errorf("sender %s already has %d pending transactions", tx.From, len(senderTxs))
+	}
+
+	entry := poolEntry{addedAt: time.Now(), size: tx.Size()}
+	var evicted *Transaction
+	if tp.maxSize > 0 && len(tp.byHash) >= tp.maxSize {
+		evicted = tp.lowestFeeEvictable(tx.From)
+		if evicted == nil || !feeRateLess(evicted.Fee, tp.entries[evicted.Hash].size, tx.Fee, entry.size) {
+			return nil, fmt.Errorf("transaction pool is full, fee per byte is too low")
+		}
+		tp.remove(evicted)
+	}
+
+	tp.byHash[tx.Hash] = tx
+	tp.entries[tx.Hash] = entry
+	if senderTxs == nil {
+		senderTxs = make(map[uint64]*Transaction)
+		tp.bySender[tx.From] = senderTxs
+	}
+	senderTxs[tx.Nonce] = tx
+	return evicted, nil
+}
+
+// ReplaceTransaction thay giao dịch đang chờ có cùng người gửi và nonce bằng tx.
+// tx phải có phí cao hơn hẳn giao dịch cũ. Trả về giao dịch bị thay thế.
+func (tp *TransactionPool) ReplaceTransaction(tx *Transaction) (*Transaction, error) {
+	tp.mutex.Lock()
+	defer tp.mutex.Unlock()
+
+	existing := tp.bySender[tx.From][tx.Nonce]
+	if existing == nil {
+		return nil, fmt.Errorf("no pending transaction from %s with nonce %d", tx.From, tx.Nonce)
+	}
+	if _, exists := tp.byHash[tx.Hash]; exists {
+		return nil, fmt.Errorf("transaction already exists in pool")
+	}
+	if tx.Fee <= existing.Fee {
+		return nil, fmt.Errorf("replacement fee %s MYC must be higher than %s MYC", tx.Fee, existing.Fee)
+	}
+
+	tp.remove(existing)
+	tp.byHash[tx.Hash] = tx
+	tp.entries[tx.Hash] = poolEntry{addedAt: time.Now(), size: tx.Size()}
+	if tp.bySender[tx.From] == nil {
+		tp.bySender[tx.From] = make(map[uint64]*Transaction)
 	}
+	tp.bySender[tx.From][tx.Nonce] = tx
+	return existing, nil
 }
 
-func (tp *TransactionPool) AddTransaction(tx *Transaction) error {
-	if !tx.IsValid() {
-		return fmt.Errorf("invalid transaction")
+// lowestFeeEvictable tìm giao dịch có phí trên mỗi byte thấp nhất trong số giao dịch
+// có nonce cao nhất của mỗi người gửi, để việc loại bỏ không tạo khoảng trống nonce.
+// Giao dịch của sender (người gửi giao dịch mới) bị bỏ qua vì giao dịch mới có thể nối
+// tiếp đúng nonce bị loại.
+func (tp *TransactionPool) lowestFeeEvictable(sender string) *Transaction {
+	var lowest *Transaction
+	for from, senderTxs := range tp.bySender {
+		if from == sender {
+			continue
+		}
+		var last *Transaction
+		for _, tx := range senderTxs {
+			if last == nil || tx.Nonce > last.Nonce {
+				last = tx
+			}
+		}
+		if last != nil && (lowest == nil || tp.feeRateLess(last, lowest)) {
+			lowest = last
+		}
 	}
+	return lowest
+}
 
-	for _, existingTx := range tp.Transactions {
-		if existingTx.Hash == tx.Hash {
-			return fmt.Errorf("transaction already exists in pool")
+func (tp *TransactionPool) remove(tx *Transaction) {
+	delete(tp.byHash, tx.Hash)
+	delete(tp.entries, tx.Hash)
+	if senderTxs := tp.bySender[tx.From]; senderTxs != nil {
+		delete(senderTxs, tx.Nonce)
+		if len(senderTxs) == 0 {
+			delete(tp.bySender, tx.From)
 		}
 	}
+}
+
+// GetTransaction tìm giao dịch đang chờ theo hash
+func (tp *TransactionPool) GetTransaction(hash string) (*Transaction, bool) {
+	tp.mutex.RLock()
+	defer tp.mutex.RUnlock()
+
+	tx, exists := tp.byHash[hash]
+	return tx, exists
+}
+
+// GetByNonce tìm giao dịch đang chờ của address có nonce cho trước
+func (tp *TransactionPool) GetByNonce(address string, nonce uint64) (*Transaction, bool) {
+	tp.mutex.RLock()
+	defer tp.mutex.RUnlock()
 
-	tp.Transactions = append(tp.Transactions, tx)
-	return nil
+	tx, exists := tp.bySender[address][nonce]
+	return tx, exists
 }
 
-func (tp *TransactionPool) GetTransactions(limit int) []*Transaction {
-	if limit <= 0 || limit > len(tp.Transactions) {
-		return tp.Transactions
+// GetBySender trả về các giao dịch đang chờ của address theo thứ tự nonce
+func (tp *TransactionPool) GetBySender(address string) []*Transaction {
+	tp.mutex.RLock()
+	defer tp.mutex.RUnlock()
+
+	return sortByNonce(tp.bySender[address])
+}
+
+// GetTransactions trả về toàn bộ giao dịch đang chờ, sắp theo người gửi rồi nonce
+func (tp *TransactionPool) GetTransactions() []*Transaction {
+	tp.mutex.RLock()
+	defer tp.mutex.RUnlock()
+
+	senders := make([]string, 0, len(tp.bySender))
+	for sender := range tp.bySender {
+		senders = append(senders, sender)
+	}
+	sort.Strings(senders)
+
+	transactions := make([]*Transaction, 0, len(tp.byHash))
+	for _, sender := range senders {
+		transactions = append(transactions, sortByNonce(tp.bySender[sender])...)
 	}
-	return tp.Transactions[:limit]
+	return transactions
 }
 
+// GetOrderedTransactions trả về các giao dịch để đưa vào block: phí trên mỗi byte cao
+// trước, nhưng giao dịch của cùng một người gửi luôn theo thứ tự nonce
+func (tp *TransactionPool) GetOrderedTransactions() []*Transaction {
+	tp.mutex.RLock()
+	defer tp.mutex.RUnlock()
+
+	queues := &senderQueues{pool: tp, queues: make([][]*Transaction, 0, len(tp.bySender))}
+	for _, senderTxs := range tp.bySender {
+		queues.queues = append(queues.queues, sortByNonce(senderTxs))
+	}
+	heap.Init(queues)
+
+	ordered := make([]*Transaction, 0, len(tp.byHash))
+	for queues.Len() > 0 {
+		head := queues.queues[0]
+		ordered = append(ordered, head[0])
+		if len(head) == 1 {
+			heap.Pop(queues)
+		} else {
+			queues.queues[0] = head[1:]
+			heap.Fix(queues, 0)
+		}
+	}
+	return ordered
+}
+
+// RemoveTransactions bỏ các giao dịch có hash trong txHashes khỏi pool
 func (tp *TransactionPool) RemoveTransactions(txHashes []string) {
+	tp.mutex.Lock()
+	defer tp.mutex.Unlock()
+
 	for _, hash := range txHashes {
-		for i, tx := range tp.Transactions {
-			if tx.Hash == hash {
-				tp.Transactions = append(tp.Transactions[:i], tp.Transactions[i+1:]...)
-				break
+		if tx, exists := tp.byHash[hash]; exists {
+			tp.remove(tx)
+		}
+	}
+}
+
+// RemoveIf bỏ khỏi pool mọi giao dịch thỏa điều kiện drop và trả về các giao dịch đã bỏ
+func (tp *TransactionPool) RemoveIf(drop func(tx *Transaction) bool) []*Transaction {
+	tp.mutex.Lock()
+	defer tp.mutex.Unlock()
+
+	var removed []*Transaction
+	for _, tx := range tp.byHash {
+		if drop(tx) {
+			tp.remove(tx)
+			removed = append(removed, tx)
+		}
+	}
+	return removed
+}
+
+// Eviction là một giao dịch bị dọn khỏi pool cùng lý do
+type Eviction struct {
+	Transaction *Transaction
+	Reason      string
+}
+
+// Sweep dọn khỏi pool các giao dịch mà reason trả về lý do khác rỗng; age là thời gian
+// giao dịch đã nằm trong pool. Các giao dịch sau (nonce lớn hơn) của cùng người gửi
+// không còn vào block được nên cũng bị dọn theo.
+func (tp *TransactionPool) Sweep(reason func(tx *Transaction, age time.Duration) string) []Eviction {
+	tp.mutex.Lock()
+	defer tp.mutex.Unlock()
+
+	now := time.Now()
+	var evictions []Eviction
+	for _, senderTxs := range tp.bySender {
+		var cause *Transaction
+		for _, tx := range sortByNonce(senderTxs) {
+			if cause != nil {
+				evictions = append(evictions, Eviction{
+					Transaction: tx,
+					Reason:      fmt.Sprintf("follows evicted transaction %s", cause.Hash),
+				})
+				continue
+			}
+			if why := reason(tx, now.Sub(tp.entries[tx.Hash].addedAt)); why != "" {
+				evictions = append(evictions, Eviction{Transaction: tx, Reason: why})
+				cause = tx
 			}
 		}
 	}
+
+	for _, eviction := range evictions {
+		tp.remove(eviction.Transaction)
+	}
+	return evictions
+}
+
+func (tp *TransactionPool) Len() int {
+	tp.mutex.RLock()
+	defer tp.mutex.RUnlock()
+
+	return len(tp.byHash)
+}
+
+// Size là kích thước (byte) của bản mã hóa nhị phân đầy đủ của giao dịch
+func (tx *Transaction) Size() int {
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// feeRateLess so sánh phí trên mỗi byte của hai giao dịch trong pool theo kích thước đã lưu
+func (tp *TransactionPool) feeRateLess(a, b *Transaction) bool {
+	return feeRateLess(a.Fee, tp.entries[a.Hash].size, b.Fee, tp.entries[b.Hash].size)
+}
+
+// feeRateLess so sánh phí trên mỗi byte aFee/aSize < bFee/bSize bằng phép nhân chéo
+func feeRateLess(aFee coin.Amount, aSize int, bFee coin.Amount, bSize int) bool {
+	aHi, aLo := bits.Mul64(uint64(aFee), uint64(bSize))
+	bHi, bLo := bits.Mul64(uint64(bFee), uint64(aSize))
+	if aHi != bHi {
+		return aHi < bHi
+	}
+	return aLo < bLo
+}
+
+func sortByNonce(senderTxs map[uint64]*Transaction) []*Transaction {
+	transactions := make([]*Transaction, 0, len(senderTxs))
+	for _, tx := range senderTxs {
+		transactions = append(transactions, tx)
+	}
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].Nonce < transactions[j].Nonce
+	})
+	return transactions
+}
+
+// senderQueues là max-heap các hàng đợi giao dịch (theo nonce) của từng người gửi,
+// so sánh bằng phí trên mỗi byte của giao dịch đầu hàng đợi
+type senderQueues struct {
+	pool   *TransactionPool
+	queues [][]*Transaction
+}
+
+func (q *senderQueues) Len() int { return len(q.queues) }
+
+func (q *senderQueues) Less(i, j int) bool {
+	a, b := q.queues[i][0], q.queues[j][0]
+	if q.pool.feeRateLess(b, a) {
+		return true
+	}
+	if q.pool.feeRateLess(a, b) {
+		return false
+	}
+	// Cùng mức phí: giao dịch cũ hơn trước để thứ tự ổn định
+	return a.Timestamp < b.Timestamp
+}
+
+func (q *senderQueues) Swap(i, j int) { q.queues[i], q.queues[j] = q.queues[j], q.queues[i] }
+
+func (q *senderQueues) Push(x interface{}) { q.queues = append(q.queues, x.([]*Transaction)) }
+
+func (q *senderQueues) Pop() interface{} {
+	old := q.queues
+	last := old[len(old)-1]
+	q.queues = old[:len(old)-1]
+	return last
 }
@@ -0,0 +1,246 @@
+package pool
+
+import (
+	"MyCoinApp/internal/coin"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// poolTransaction tạo giao dịch chưa ký của sender; các giao dịch có cùng kích thước nên
+// phí trên mỗi byte tỉ lệ với fee
+func poolTransaction(sender string, nonce uint64, fee coin.Amount) *Transaction {
+	tx := &Transaction{
+		Version:   CurrentTxVersion,
+		Type:      TxTransfer,
+		From:      fmt.Sprintf("00%048s", sender),
+		To:        strings.Repeat("0f", 25),
+		Amount:    coin.Unit,
+		Fee:       fee,
+		Nonce:     nonce,
+		Timestamp: 1700000000 + int64(nonce),
+	}
+	tx.Hash = tx.CalculateHash()
+	return tx
+}
+
+func mustAdd(t *testing.T, tp *TransactionPool, tx *Transaction) {
+	t.Helper()
+	if _, err := tp.AddTransaction(tx); err != nil {
+		t.Fatalf("AddTransaction(%s nonce %d): %v", tx.From, tx.Nonce, err)
+	}
+}
+
+func TestOrderedTransactionsByFeeRate(t *testing.T) {
+	tp := NewTransactionPool(100, 10)
+	low := poolTransaction("a", 0, 100)
+	high := poolTransaction("b", 0, 300)
+	mid := poolTransaction("c", 0, 200)
+	for _, tx := range []*Transaction{low, high, mid} {
+		mustAdd(t, tp, tx)
+	}
+
+	ordered := tp.GetOrderedTransactions()
+	want := []*Transaction{high, mid, low}
+	for i := range want {
+		if ordered[i] != want[i] {
+			t.Fatalf("position %d: got fee %s, want fee %s", i, ordered[i].Fee, want[i].Fee)
+		}
+	}
+}
+
+// Giao dịch của cùng người gửi luôn theo thứ tự nonce, kể cả khi nonce sau trả phí cao hơn
+func TestOrderedTransactionsKeepNonceOrder(t *testing.T) {
+	tp := NewTransactionPool(100, 10)
+	a0 := poolTransaction("a", 0, 100)
+	a1 := poolTransaction("a", 1, 500)
+	b0 := poolTransaction("b", 0, 300)
+	for _, tx := range []*Transaction{a1, b0, a0} {
+		mustAdd(t, tp, tx)
+	}
+
+	ordered := tp.GetOrderedTransactions()
+	want := []*Transaction{b0, a0, a1}
+	if len(ordered) != len(want) {
+		t.Fatalf("got %d transactions, want %d", len(ordered), len(want))
+	}
+	for i := range want {
+		if ordered[i] != want[i] {
+			t.Fatalf("position %d: got %s nonce %d, want %s nonce %d",
+				i, ordered[i].From, ordered[i].Nonce, want[i].From, want[i].Nonce)
+		}
+	}
+}
+
+// Cùng phí trên mỗi byte: giao dịch cũ hơn trước
+func TestOrderedTransactionsTieBreakByTimestamp(t *testing.T) {
+	tp := NewTransactionPool(100, 10)
+	newer := poolTransaction("a", 0, 100)
+	newer.Timestamp += 10
+	newer.Hash = newer.CalculateHash()
+	older := poolTransaction("b", 0, 100)
+	mustAdd(t, tp, newer)
+	mustAdd(t, tp, older)
+
+	ordered := tp.GetOrderedTransactions()
+	if ordered[0] != older || ordered[1] != newer {
+		t.Fatal("transactions with the same fee rate are not ordered by timestamp")
+	}
+}
+
+func TestFullPoolEvictsLowestFeeRate(t *testing.T) {
+	tp := NewTransactionPool(3, 10)
+	a0 := poolTransaction("a", 0, 100)
+	b0 := poolTransaction("b", 0, 200)
+	c0 := poolTransaction("c", 0, 300)
+	for _, tx := range []*Transaction{a0, b0, c0} {
+		mustAdd(t, tp, tx)
+	}
+
+	if _, err := tp.AddTransaction(poolTransaction("d", 0, 50)); err == nil {
+		t.Fatal("a full pool accepted a transaction with a lower fee rate than every pending one")
+	}
+
+	evicted, err := tp.AddTransaction(poolTransaction("d", 0, 150))
+	if err != nil {
+		t.Fatalf("AddTransaction: %v", err)
+	}
+	if evicted != a0 {
+		t.Fatalf("evicted %v, want the lowest fee transaction", evicted)
+	}
+	if _, exists := tp.GetTransaction(a0.Hash); exists {
+		t.Error("evicted transaction is still in the pool")
+	}
+	if tp.Len() != 3 {
+		t.Errorf("pool has %d transactions, want 3", tp.Len())
+	}
+}
+
+// Chỉ giao dịch có nonce cao nhất của mỗi người gửi bị loại để không tạo khoảng trống nonce,
+// và giao dịch của chính người gửi giao dịch mới không bị loại
+func TestFullPoolEvictsOnlyLastNonceOfOtherSenders(t *testing.T) {
+	tp := NewTransactionPool(3, 10)
+	a0 := poolTransaction("a", 0, 10)
+	a1 := poolTransaction("a", 1, 400)
+	b0 := poolTransaction("b", 0, 200)
+	for _, tx := range []*Transaction{a0, a1, b0} {
+		mustAdd(t, tp, tx)
+	}
+
+	evicted, err := tp.AddTransaction(poolTransaction("c", 0, 300))
+	if err != nil {
+		t.Fatalf("AddTransaction: %v", err)
+	}
+	if evicted != b0 {
+		t.Fatalf("evicted %v, want b's transaction (a's lowest fee transaction is not its last nonce)", evicted)
+	}
+
+	// a đã có giao dịch phí thấp nhất nhưng giao dịch mới của a không được loại giao dịch của a
+	if _, err := tp.AddTransaction(poolTransaction("a", 2, 250)); err == nil {
+		t.Fatal("a full pool accepted a transaction that only outbids the sender's own transactions")
+	}
+}
+
+func TestPoolRejectsDuplicatesAndSenderLimit(t *testing.T) {
+	tp := NewTransactionPool(100, 2)
+	a0 := poolTransaction("a", 0, 100)
+	mustAdd(t, tp, a0)
+
+	if _, err := tp.AddTransaction(a0); err == nil {
+		t.Error("the same transaction was added twice")
+	}
+	if _, err := tp.AddTransaction(poolTransaction("a", 0, 200)); err == nil {
+		t.Error("a second transaction with the same nonce was added")
+	}
+
+	mustAdd(t, tp, poolTransaction("a", 1, 100))
+	if _, err := tp.AddTransaction(poolTransaction("a", 2, 100)); err == nil {
+		t.Error("a sender exceeded the per-sender limit")
+	}
+}
+
+func TestReplaceTransactionRequiresHigherFee(t *testing.T) {
+	tp := NewTransactionPool(100, 10)
+	original := poolTransaction("a", 0, 100)
+	mustAdd(t, tp, original)
+
+	if _, err := tp.ReplaceTransaction(poolTransaction("a", 0, 100)); err == nil {
+		t.Error("replacement with the same fee was accepted")
+	}
+
+	replacement := poolTransaction("a", 0, 150)
+	replaced, err := tp.ReplaceTransaction(replacement)
+	if err != nil {
+		t.Fatalf("ReplaceTransaction: %v", err)
+	}
+	if replaced != original {
+		t.Error("ReplaceTransaction did not return the replaced transaction")
+	}
+	if tx, _ := tp.GetByNonce(original.From, 0); tx != replacement {
+		t.Error("the replacement is not pending at the nonce")
+	}
+	if _, exists := tp.GetTransaction(original.Hash); exists {
+		t.Error("the replaced transaction is still in the pool")
+	}
+}
+
+// Khi một giao dịch bị dọn, các giao dịch sau của cùng người gửi cũng bị dọn
+func TestSweepEvictsFollowingNonces(t *testing.T) {
+	tp := NewTransactionPool(100, 10)
+	a0 := poolTransaction("a", 0, 100)
+	a1 := poolTransaction("a", 1, 100)
+	a2 := poolTransaction("a", 2, 100)
+	b0 := poolTransaction("b", 0, 100)
+	for _, tx := range []*Transaction{a0, a1, a2, b0} {
+		mustAdd(t, tp, tx)
+	}
+
+	evictions := tp.Sweep(func(tx *Transaction, age time.Duration) string {
+		if age < 0 {
+			t.Errorf("negative age %s", age)
+		}
+		if tx == a1 {
+			return "expired"
+		}
+		return ""
+	})
+
+	if len(evictions) != 2 || evictions[0].Transaction != a1 || evictions[1].Transaction != a2 {
+		t.Fatalf("evictions = %+v, want a1 then a2", evictions)
+	}
+	if tp.Len() != 2 {
+		t.Errorf("pool has %d transactions, want 2", tp.Len())
+	}
+}
+
+// Kích thước được tính một lần khi thêm vào pool và khớp với bản mã hóa của giao dịch
+func TestPoolRecordsTransactionSize(t *testing.T) {
+	tp := NewTransactionPool(100, 10)
+	tx := poolTransaction("a", 0, 100)
+	mustAdd(t, tp, tx)
+
+	if size := tp.entries[tx.Hash].size; size != tx.Size() || size == 0 {
+		t.Errorf("recorded size %d, want %d", size, tx.Size())
+	}
+
+	tp.RemoveTransactions([]string{tx.Hash})
+	if _, exists := tp.entries[tx.Hash]; exists {
+		t.Error("entry of a removed transaction is still recorded")
+	}
+}
+
+func TestFeeRateLessComparesPerByte(t *testing.T) {
+	// 100/50 = 2 < 300/100 = 3
+	if !feeRateLess(100, 50, 300, 100) {
+		t.Error("2 per byte is not less than 3 per byte")
+	}
+	// 200/100 = 2 không nhỏ hơn 100/50 = 2
+	if feeRateLess(200, 100, 100, 50) {
+		t.Error("equal fee rates compare as less")
+	}
+	// Tích vượt quá 64 bit vẫn so sánh đúng
+	if !feeRateLess(coin.Amount(1<<63), 4, coin.Amount(1<<63), 2) {
+		t.Error("large fee rates compare incorrectly")
+	}
+}
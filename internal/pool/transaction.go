@@ -1,33 +1,78 @@
 package pool
 
 import (
+	"MyCoinApp/internal/coin"
+	"MyCoinApp/internal/wallet"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"math/big"
-	"strconv"
 	"time"
 )
 
+type TransactionType string
+
+const (
+	// TxTransfer là giao dịch chuyển coin thông thường, bắt buộc có chữ ký của người gửi
+	TxTransfer TransactionType = "transfer"
+	// TxReward là giao dịch thưởng (coinbase) do node tạo ra cho validator, không có người gửi
+	TxReward TransactionType = "reward"
+	// TxGenesis phân bổ coin ban đầu, chỉ xuất hiện trong genesis block
+	TxGenesis TransactionType = "genesis"
+	// TxStake khóa Amount coin của người gửi để trở thành validator
+	TxStake TransactionType = "stake"
+	// TxUnstake rút toàn bộ stake của người gửi về số dư (Amount = 0)
+	TxUnstake TransactionType = "unstake"
+)
+
 type Transaction struct {
-	From      string  `json:"from"`
-	To        string  `json:"to"`
-	Amount    float64 `json:"amount"`
-	Fee       float64 `json:"fee"`
-	Timestamp int64   `json:"timestamp"`
-	Hash      string  `json:"hash"`
-	Signature string  `json:"signature"`
+	// Version là phiên bản mã hóa chuẩn dùng để tính Hash (xem TxVersion1)
+	Version   uint8           `json:"version"`
+	Type      TransactionType `json:"type"`
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	Amount    coin.Amount     `json:"amount"`
+	Fee       coin.Amount     `json:"fee"`
+	Nonce     uint64          `json:"nonce"`
+	Timestamp int64           `json:"timestamp"`
+	Hash      string          `json:"hash"`
+	PublicKey string          `json:"public_key"`
+	Signature string          `json:"signature"`
+	// Fees ghi lại cách chia phí của block, chỉ có ở giao dịch thưởng (TxVersion2)
+	Fees *FeeDistribution `json:"fees,omitempty"`
+	// ValidUntilHeight là index block cuối cùng được phép chứa giao dịch, 0 là không hết hạn (TxVersion3)
+	ValidUntilHeight int64 `json:"valid_until_height,omitempty"`
 }
 
-func NewTransaction(from, to string, amount, fee float64) *Transaction {
+// FeeDistribution là cách chia tổng phí giao dịch của một block. Phần của validator
+// đã được cộng vào Amount của giao dịch thưởng.
+type FeeDistribution struct {
+	Collected      coin.Amount `json:"collected"`
+	Validator      coin.Amount `json:"validator"`
+	Burned         coin.Amount `json:"burned"`
+	Treasury       string      `json:"treasury,omitempty"`
+	TreasuryAmount coin.Amount `json:"treasury_amount"`
+}
+
+// Total là tổng các phần đã chia, phải bằng Collected
+func (f *FeeDistribution) Total() (coin.Amount, error) {
+	return coin.Sum(f.Validator, f.Burned, f.TreasuryAmount)
+}
+
+// NewTransaction tạo giao dịch chuyển coin; nonce phải bằng nonce kế tiếp của người gửi
+func NewTransaction(from, to string, amount, fee coin.Amount, nonce uint64) *Transaction {
 	tx := &Transaction{
+		Version:   CurrentTxVersion,
+		Type:      TxTransfer,
 		From:      from,
 		To:        to,
 		Amount:    amount,
 		Fee:       fee,
+		Nonce:     nonce,
 		Timestamp: time.Now().Unix(),
 	}
 
@@ -35,18 +80,106 @@ func NewTransaction(from, to string, amount, fee float64) *Transaction {
 	return tx
 }
 
+// NewCancelTransaction tạo giao dịch hủy giao dịch đang chờ có cùng nonce: chuyển 0 coin
+// cho chính mình với phí fee (phải cao hơn phí của giao dịch bị hủy)
+func NewCancelTransaction(from string, fee coin.Amount, nonce uint64) *Transaction {
+	return NewTransaction(from, from, 0, fee, nonce)
+}
+
+// NewRewardTransaction tạo giao dịch thưởng cho validator tạo block. amount gồm
+// thưởng block và phần phí của validator, fees ghi lại cách chia phí của block.
+func NewRewardTransaction(to string, amount coin.Amount, fees *FeeDistribution) *Transaction {
+	tx := &Transaction{
+		Version:   CurrentTxVersion,
+		Type:      TxReward,
+		To:        to,
+		Amount:    amount,
+		Timestamp: time.Now().Unix(),
+		Fees:      fees,
+	}
+
+	tx.Hash = tx.CalculateHash()
+	return tx
+}
+
+// NewStakeTransaction tạo giao dịch stake amount coin của from
+func NewStakeTransaction(from string, amount, fee coin.Amount, nonce uint64) *Transaction {
+	tx := &Transaction{
+		Version:   CurrentTxVersion,
+		Type:      TxStake,
+		From:      from,
+		Amount:    amount,
+		Fee:       fee,
+		Nonce:     nonce,
+		Timestamp: time.Now().Unix(),
+	}
+
+	tx.Hash = tx.CalculateHash()
+	return tx
+}
+
+// NewUnstakeTransaction tạo giao dịch rút toàn bộ stake của from
+func NewUnstakeTransaction(from string, fee coin.Amount, nonce uint64) *Transaction {
+	tx := &Transaction{
+		Version:   CurrentTxVersion,
+		Type:      TxUnstake,
+		From:      from,
+		Fee:       fee,
+		Nonce:     nonce,
+		Timestamp: time.Now().Unix(),
+	}
+
+	tx.Hash = tx.CalculateHash()
+	return tx
+}
+
+// NewGenesisTransaction tạo giao dịch phân bổ coin ban đầu trong genesis block.
+// Luôn dùng TxVersion1 để hash của genesis block không đổi giữa các phiên bản.
+func NewGenesisTransaction(to string, amount coin.Amount, timestamp int64) *Transaction {
+	tx := &Transaction{
+		Version:   TxVersion1,
+		Type:      TxGenesis,
+		To:        to,
+		Amount:    amount,
+		Timestamp: timestamp,
+	}
+
+	tx.Hash = tx.CalculateHash()
+	return tx
+}
+
+// IsExpired cho biết giao dịch không còn được đưa vào block có index height
+func (tx *Transaction) IsExpired(height int64) bool {
+	return tx.ValidUntilHeight != 0 && height > tx.ValidUntilHeight
+}
+
+// Cost là tổng số coin người gửi bị trừ (amount + fee)
+func (tx *Transaction) Cost() (coin.Amount, error) {
+	return tx.Amount.Add(tx.Fee)
+}
+
+func (tx *Transaction) IsReward() bool {
+	return tx.Type == TxReward
+}
+
+// IsSystem cho biết giao dịch do chain tạo ra (thưởng, genesis), không có người gửi và chữ ký
+func (tx *Transaction) IsSystem() bool {
+	return tx.Type == TxReward || tx.Type == TxGenesis
+}
+
+// CalculateHash tính sha256 của bản mã hóa chuẩn; trả về chuỗi rỗng nếu Version không được hỗ trợ
 func (tx *Transaction) CalculateHash() string {
-	data := tx.From + tx.To +
-		strconv.FormatFloat(tx.Amount, 'f', -1, 64) +
-		strconv.FormatFloat(tx.Fee, 'f', -1, 64) +
-		strconv.FormatInt(tx.Timestamp, 10)
+	data, err := tx.EncodeForHash()
+	if err != nil {
+		return ""
+	}
 
-	hash := sha256.Sum256([]byte(data))
+	hash := sha256.Sum256(data)
 	return hex.EncodeToString(hash[:])
 }
 
 func (tx *Transaction) SignTransaction(privateKey *ecdsa.PrivateKey) error {
-	if tx.From == "" || tx.From == "genesis" {
+	if tx.IsSystem() {
 		return nil
 	}
 
@@ -60,14 +193,22 @@ func (tx *Transaction) SignTransaction(privateKey *ecdsa.PrivateKey) error {
 		return err
 	}
 
-	signature := append(r.Bytes(), s.Bytes()...)
+	// r và s luôn được ghi đủ 32 byte để VerifySignature tách đúng
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
 	tx.Signature = hex.EncodeToString(signature)
 
+	publicKey := make([]byte, 64)
+	privateKey.PublicKey.X.FillBytes(publicKey[:32])
+	privateKey.PublicKey.Y.FillBytes(publicKey[32:])
+	tx.PublicKey = hex.EncodeToString(publicKey)
+
 	return nil
 }
 
 func (tx *Transaction) VerifySignature(publicKey []byte) bool {
-	if tx.From == "" || tx.From == "genesis" {
+	if tx.IsSystem() {
 		return true
 	}
 
@@ -84,6 +225,10 @@ func (tx *Transaction) VerifySignature(publicKey []byte) bool {
 		return false
 	}
 
+	if len(publicKey) != 64 {
+		return false
+	}
+
 	r := big.NewInt(0).SetBytes(signatureBytes[:32])
 	s := big.NewInt(0).SetBytes(signatureBytes[32:])
 
@@ -104,20 +249,93 @@ func (tx *Transaction) VerifySignature(publicKey []byte) bool {
 	return ecdsa.Verify(&pubKey, hashBytes, r, s)
 }
 
+// Verify kiểm tra giao dịch được ký đúng bởi chủ sở hữu địa chỉ From.
+// Giao dịch thưởng và genesis không có người gửi nên không cần chữ ký.
+func (tx *Transaction) Verify() error {
+	if tx.IsSystem() {
+		if tx.From != "" {
+			return fmt.Errorf("%s transaction must not have a sender", tx.Type)
+		}
+		return nil
+	}
+
+	switch tx.Type {
+	case TxTransfer, TxStake, TxUnstake:
+	default:
+		return fmt.Errorf("unknown transaction type %q", tx.Type)
+	}
+
+	if tx.PublicKey == "" || tx.Signature == "" {
+		return fmt.Errorf("transaction is not signed")
+	}
+
+	publicKey, err := hex.DecodeString(tx.PublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key encoding")
+	}
+
+	if wallet.AddressFromPublicKey(publicKey) != tx.From {
+		return fmt.Errorf("public key does not match from address")
+	}
+
+	if !tx.VerifySignature(publicKey) {
+		return fmt.Errorf("invalid transaction signature")
+	}
+
+	return nil
+}
+
 func (tx *Transaction) IsValid() bool {
-	if tx.From == tx.To {
+	switch tx.Type {
+	case TxTransfer:
+		// Chuyển cho chính mình với Amount = 0 là giao dịch hủy (xem NewCancelTransaction)
+		if tx.To == "" || (tx.From != tx.To && tx.Amount.IsZero()) {
+			return false
+		}
+	case TxStake:
+		// Stake không có người nhận
+		if tx.To != "" || tx.Amount.IsZero() {
+			return false
+		}
+	case TxUnstake:
+		// Unstake luôn rút toàn bộ stake
+		if tx.To != "" || !tx.Amount.IsZero() {
+			return false
+		}
+	case TxReward, TxGenesis:
+		if tx.To == "" || tx.Amount.IsZero() || !tx.Fee.IsZero() {
+			return false
+		}
+	default:
 		return false
 	}
 
-	if tx.Amount <= 0 {
+	// Chỉ giao dịch thưởng mới ghi lại cách chia phí
+	if tx.Fees != nil {
+		if tx.Type != TxReward {
+			return false
+		}
+		total, err := tx.Fees.Total()
+		if err != nil || total != tx.Fees.Collected || tx.Fees.Validator > tx.Amount {
+			return false
+		}
+		if !tx.Fees.TreasuryAmount.IsZero() && tx.Fees.Treasury == "" {
+			return false
+		}
+	}
+
+	// Giao dịch hệ thống không hết hạn
+	if tx.ValidUntilHeight < 0 || (tx.IsSystem() && tx.ValidUntilHeight != 0) {
 		return false
 	}
 
-	if tx.Fee < 0 {
+	// Amount + Fee không được tràn số
+	if _, err := tx.Amount.Add(tx.Fee); err != nil {
 		return false
 	}
 
-	if tx.Hash != tx.CalculateHash() {
+	// CalculateHash trả về chuỗi rỗng với phiên bản không được hỗ trợ
+	if tx.Hash == "" || tx.Hash != tx.CalculateHash() {
 		return false
 	}
 
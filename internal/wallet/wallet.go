@@ -6,6 +6,7 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"math/big"
 
 	"golang.org/x/crypto/ripemd160"
@@ -20,8 +21,8 @@ type Wallet struct {
 func NewWallet() *Wallet {
 	// Tạo cặp khóa công khai và khóa riêng
 	privateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-	publicKey := append(privateKey.PublicKey.X.Bytes(), privateKey.PublicKey.Y.Bytes()...)
-	address := generateAddress(publicKey)
+	publicKey := encodePublicKey(&privateKey.PublicKey)
+	address := AddressFromPublicKey(publicKey)
 
 	return &Wallet{
 		Address:    address,
@@ -45,6 +46,42 @@ func generateAddress(publicKey []byte) string {
 	return hex.EncodeToString(fullPayload)
 }
 
+// encodePublicKey mã hóa public key thành X||Y, mỗi tọa độ đủ 32 byte
+func encodePublicKey(publicKey *ecdsa.PublicKey) []byte {
+	encoded := make([]byte, 64)
+	publicKey.X.FillBytes(encoded[:32])
+	publicKey.Y.FillBytes(encoded[32:])
+	return encoded
+}
+
+// AddressFromPublicKey trả về địa chỉ ví tương ứng với public key (X||Y).
+// Địa chỉ được băm từ X||Y không đệm byte 0 ở đầu mỗi tọa độ, đúng như cách ví được tạo
+// trước khi public key được mã hóa đủ 64 byte, để ví cũ có X hoặc Y bắt đầu bằng byte 0
+// (khoảng 1/128 số ví) giữ nguyên địa chỉ.
+func AddressFromPublicKey(publicKey []byte) string {
+	if len(publicKey) != 64 {
+		return generateAddress(publicKey)
+	}
+	x := new(big.Int).SetBytes(publicKey[:32]).Bytes()
+	y := new(big.Int).SetBytes(publicKey[32:]).Bytes()
+	return generateAddress(append(x, y...))
+}
+
+// ParsePublicKey đọc public key X||Y dạng hex và kiểm tra điểm nằm trên đường cong P-256
+func ParsePublicKey(publicKeyHex string) ([]byte, error) {
+	publicKey, err := hex.DecodeString(publicKeyHex)
+	if err != nil || len(publicKey) != 64 {
+		return nil, fmt.Errorf("invalid public key")
+	}
+
+	x := new(big.Int).SetBytes(publicKey[:32])
+	y := new(big.Int).SetBytes(publicKey[32:])
+	if !elliptic.P256().IsOnCurve(x, y) {
+		return nil, fmt.Errorf("public key is not a point on P-256")
+	}
+	return publicKey, nil
+}
+
 func checksum(payload []byte) []byte {
 	firstSHA := sha256.Sum256(payload)
 	secondSHA := sha256.Sum256(firstSHA[:])
@@ -70,8 +107,8 @@ func LoadWalletFromPrivateKey(privateKeyHex string) (*Wallet, error) {
 	privateKey.D = new(big.Int).SetBytes(privateKeyBytes)
 	privateKey.PublicKey.X, privateKey.PublicKey.Y = privateKey.PublicKey.Curve.ScalarBaseMult(privateKeyBytes)
 
-	publicKey := append(privateKey.PublicKey.X.Bytes(), privateKey.PublicKey.Y.Bytes()...)
-	address := generateAddress(publicKey)
+	publicKey := encodePublicKey(&privateKey.PublicKey)
+	address := AddressFromPublicKey(publicKey)
 
 	return &Wallet{
 		PrivateKey: privateKey,
@@ -87,8 +124,8 @@ func NewWalletFromPassphrase(passphrase string) *Wallet {
 	privateKey.D = new(big.Int).SetBytes(hash[:])
 	privateKey.PublicKey.X, privateKey.PublicKey.Y = privateKey.PublicKey.Curve.ScalarBaseMult(hash[:])
 
-	publicKey := append(privateKey.PublicKey.X.Bytes(), privateKey.PublicKey.Y.Bytes()...)
-	address := generateAddress(publicKey)
+	publicKey := encodePublicKey(&privateKey.PublicKey)
+	address := AddressFromPublicKey(publicKey)
 
 	return &Wallet{
 		PrivateKey: privateKey,
@@ -96,3 +133,44 @@ func NewWalletFromPassphrase(passphrase string) *Wallet {
 		Address:    address,
 	}
 }
+
+// SignHash ký hash bằng private key của ví, trả về chữ ký r||s (mỗi phần 32 byte) dạng hex
+func (w *Wallet) SignHash(hash []byte) (string, error) {
+	r, s, err := ecdsa.Sign(rand.Reader, w.PrivateKey, hash)
+	if err != nil {
+		return "", err
+	}
+
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return hex.EncodeToString(signature), nil
+}
+
+// VerifyHash kiểm tra chữ ký r||s của hash với public key X||Y (dạng hex)
+// và trả về địa chỉ ví sở hữu public key đó
+func VerifyHash(publicKeyHex string, hash []byte, signatureHex string) (string, error) {
+	publicKey, err := hex.DecodeString(publicKeyHex)
+	if err != nil || len(publicKey) != 64 {
+		return "", fmt.Errorf("invalid public key")
+	}
+
+	signature, err := hex.DecodeString(signatureHex)
+	if err != nil || len(signature) != 64 {
+		return "", fmt.Errorf("invalid signature encoding")
+	}
+
+	pubKey := ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(publicKey[:32]),
+		Y:     new(big.Int).SetBytes(publicKey[32:]),
+	}
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+
+	if !ecdsa.Verify(&pubKey, hash, r, s) {
+		return "", fmt.Errorf("invalid signature")
+	}
+
+	return AddressFromPublicKey(publicKey), nil
+}
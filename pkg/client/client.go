@@ -0,0 +1,478 @@
+// Package client là Go client có kiểu cho REST API và JSON-RPC của node MyCoin.
+// Mỗi endpoint trong tài liệu /api/openapi.json có một method tương ứng; lỗi do node
+// trả về có kiểu *APIError (REST) hoặc *models.RPCError (JSON-RPC).
+package client
+
+import (
+	"MyCoinApp/internal/blockchain"
+	"MyCoinApp/internal/consensus"
+	"MyCoinApp/internal/models"
+	"MyCoinApp/internal/pool"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Client gọi API của một node. An toàn khi dùng đồng thời.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+
+	// AdminToken được gửi trong header X-Admin-Token khi gọi API quản trị
+	AdminToken string
+}
+
+// NewClient tạo client cho node tại baseURL (ví dụ http://localhost:8080);
+// httpClient nil thì dùng http.DefaultClient
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// APIError là response lỗi của REST API (HTTP status khác 2xx)
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("mycoin: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// IsNotFound cho biết err là lỗi 404 của node (không tìm thấy block, giao dịch, validator...)
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// request gửi request tới path và trả về response thành công; body khác nil được mã hóa JSON
+func (c *Client) request(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Response, error) {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.AdminToken != "" {
+		req.Header.Set("X-Admin-Token", c.AdminToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		var errorResponse models.ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errorResponse); err == nil && errorResponse.Error != "" {
+			apiErr.Message = errorResponse.Error
+		} else {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return nil, apiErr
+	}
+	return resp, nil
+}
+
+// do gửi request và giải mã body JSON của response vào result
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, result interface{}) error {
+	resp, err := c.request(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("decode %s response: %w", path, err)
+	}
+	return nil
+}
+
+func pathf(format string, args ...interface{}) string {
+	for i, arg := range args {
+		if value, ok := arg.(string); ok {
+			args[i] = url.PathEscape(value)
+		}
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// GetOpenAPI trả về tài liệu OpenAPI của node
+func (c *Client) GetOpenAPI(ctx context.Context) (map[string]interface{}, error) {
+	var document map[string]interface{}
+	if err := c.do(ctx, http.MethodGet, "/api/openapi.json", nil, nil, &document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// Wallet
+
+func (c *Client) CreateWallet(ctx context.Context) (*models.CreateWalletResponse, error) {
+	var response models.CreateWalletResponse
+	if err := c.do(ctx, http.MethodPost, "/api/wallet/create", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) ImportWallet(ctx context.Context, request *models.ImportWalletRequest) (*models.CreateWalletResponse, error) {
+	var response models.CreateWalletResponse
+	if err := c.do(ctx, http.MethodPost, "/api/wallet/import", nil, request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) GetBalance(ctx context.Context, address string) (*models.BalanceResponse, error) {
+	var response models.BalanceResponse
+	if err := c.do(ctx, http.MethodGet, pathf("/api/wallet/balance/%s", address), nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) GetNonce(ctx context.Context, address string) (*models.NonceResponse, error) {
+	var response models.NonceResponse
+	if err := c.do(ctx, http.MethodGet, pathf("/api/wallet/nonce/%s", address), nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Blockchain
+
+func (c *Client) MineBlock(ctx context.Context, request *models.MineBlockRequest) (*models.MineBlockResponse, error) {
+	var response models.MineBlockResponse
+	if err := c.do(ctx, http.MethodPost, "/api/blockchain/mine", nil, request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetBlockTemplate trả về block chưa ký cho minerAddress. Validator ký BlockHash rồi
+// gửi block qua SubmitBlock.
+func (c *Client) GetBlockTemplate(ctx context.Context, minerAddress string) (*blockchain.Block, error) {
+	var response struct {
+		Block *blockchain.Block `json:"block"`
+	}
+	request := &models.BlockTemplateRequest{MinerAddress: minerAddress}
+	if err := c.do(ctx, http.MethodPost, "/api/blockchain/mine/template", nil, request, &response); err != nil {
+		return nil, err
+	}
+	if response.Block == nil {
+		return nil, fmt.Errorf("block template response has no block")
+	}
+	return response.Block, nil
+}
+
+func (c *Client) SubmitBlock(ctx context.Context, block *blockchain.Block) (*models.MineBlockResponse, error) {
+	var response models.MineBlockResponse
+	if err := c.do(ctx, http.MethodPost, "/api/blockchain/mine/submit", nil, block, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) GetBlockchainInfo(ctx context.Context) (*models.BlockchainInfoResponse, error) {
+	var response models.BlockchainInfoResponse
+	if err := c.do(ctx, http.MethodGet, "/api/blockchain/info", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetMerkleProof trả về Merkle proof của giao dịch đã xác nhận; kiểm tra bằng VerifyMerkleProof
+func (c *Client) GetMerkleProof(ctx context.Context, txHash string) (*models.MerkleProofResponse, error) {
+	var response models.MerkleProofResponse
+	if err := c.do(ctx, http.MethodGet, pathf("/api/blockchain/proof/%s", txHash), nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// VerifyMerkleProof kiểm tra proof chứng minh giao dịch nằm trong block có Merkle root
+// merkleRoot. merkleRoot phải lấy từ header block mà client đã kiểm tra, không lấy từ proof.
+func VerifyMerkleProof(proof *models.MerkleProof, merkleRoot string) bool {
+	return blockchain.VerifyMerkleProof(proof.BlockVersion, proof.TxHash, merkleRoot, proof.Proof)
+}
+
+// GetBlocks trả về một trang block; query nil hoặc trường rỗng dùng mặc định của node
+func (c *Client) GetBlocks(ctx context.Context, query *models.BlockListQuery) (*models.BlockListResponse, error) {
+	values := url.Values{}
+	if query != nil {
+		if query.From != nil {
+			values.Set("from", strconv.FormatInt(*query.From, 10))
+		}
+		if query.Limit > 0 {
+			values.Set("limit", strconv.Itoa(query.Limit))
+		}
+		if query.Order != "" {
+			values.Set("order", query.Order)
+		}
+	}
+
+	var response models.BlockListResponse
+	if err := c.do(ctx, http.MethodGet, "/api/blockchain/blocks", values, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) GetBlock(ctx context.Context, index int64) (*models.BlockResponse, error) {
+	var response models.BlockResponse
+	if err := c.do(ctx, http.MethodGet, pathf("/api/blockchain/block/%d", index), nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) GetBlockByHash(ctx context.Context, hash string) (*models.BlockResponse, error) {
+	var response models.BlockResponse
+	if err := c.do(ctx, http.MethodGet, pathf("/api/blockchain/block/hash/%s", hash), nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Staking
+
+func (c *Client) Stake(ctx context.Context, request *models.StakeRequest) (*models.StakeResponse, error) {
+	var response models.StakeResponse
+	if err := c.do(ctx, http.MethodPost, "/api/staking/stake", nil, request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) Unstake(ctx context.Context, request *models.UnstakeRequest) (*models.StakeResponse, error) {
+	var response models.StakeResponse
+	if err := c.do(ctx, http.MethodPost, "/api/staking/unstake", nil, request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// StakeSigned gửi giao dịch stake đã ký ở phía client
+func (c *Client) StakeSigned(ctx context.Context, tx *pool.Transaction) (*models.StakeResponse, error) {
+	var response models.StakeResponse
+	if err := c.do(ctx, http.MethodPost, "/api/staking/stake/signed", nil, tx, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// UnstakeSigned gửi giao dịch unstake đã ký ở phía client
+func (c *Client) UnstakeSigned(ctx context.Context, tx *pool.Transaction) (*models.StakeResponse, error) {
+	var response models.StakeResponse
+	if err := c.do(ctx, http.MethodPost, "/api/staking/unstake/signed", nil, tx, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) GetValidators(ctx context.Context) (*models.ValidatorsResponse, error) {
+	var response models.ValidatorsResponse
+	if err := c.do(ctx, http.MethodGet, "/api/staking/validators", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) GetValidator(ctx context.Context, address string) (*consensus.Validator, error) {
+	var response consensus.Validator
+	if err := c.do(ctx, http.MethodGet, pathf("/api/staking/validator/%s", address), nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) GetStakingInfo(ctx context.Context) (*models.StakingInfoResponse, error) {
+	var response models.StakingInfoResponse
+	if err := c.do(ctx, http.MethodGet, "/api/staking/info", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Network
+
+func (c *Client) GetPeers(ctx context.Context) (*models.PeersResponse, error) {
+	var response models.PeersResponse
+	if err := c.do(ctx, http.MethodGet, "/api/network/peers", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Admin
+
+// ValidateChain yêu cầu AdminToken, hoặc gọi từ localhost nếu node không cấu hình token
+func (c *Client) ValidateChain(ctx context.Context) (*models.ValidationResult, error) {
+	var response models.ValidationResult
+	if err := c.do(ctx, http.MethodGet, "/api/admin/validate-chain", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Transaction
+
+func (c *Client) SendTransaction(ctx context.Context, request *models.SendTransactionRequest) (*models.TransactionSubmitResponse, error) {
+	var response models.TransactionSubmitResponse
+	if err := c.do(ctx, http.MethodPost, "/api/transaction/send", nil, request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// BroadcastTransaction gửi giao dịch đã ký ở phía client
+func (c *Client) BroadcastTransaction(ctx context.Context, tx *pool.Transaction) (*models.TransactionSubmitResponse, error) {
+	var response models.TransactionSubmitResponse
+	if err := c.do(ctx, http.MethodPost, "/api/transaction/broadcast", nil, tx, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ReplaceTransaction thay giao dịch đang chờ cùng nonce bằng tx đã ký có phí cao hơn
+func (c *Client) ReplaceTransaction(ctx context.Context, tx *pool.Transaction) (*models.TransactionSubmitResponse, error) {
+	var response models.TransactionSubmitResponse
+	if err := c.do(ctx, http.MethodPost, "/api/transaction/replace", nil, tx, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) CancelTransaction(ctx context.Context, request *models.CancelTransactionRequest) (*models.TransactionSubmitResponse, error) {
+	var response models.TransactionSubmitResponse
+	if err := c.do(ctx, http.MethodPost, "/api/transaction/cancel", nil, request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetTransactionHistory trả về một trang lịch sử giao dịch; query nil dùng mặc định của node
+func (c *Client) GetTransactionHistory(ctx context.Context, address string, query *models.TransactionHistoryQuery) (*models.TransactionHistoryResponse, error) {
+	var response models.TransactionHistoryResponse
+	if err := c.do(ctx, http.MethodGet, pathf("/api/transaction/history/%s", address), historyValues(query), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func historyValues(query *models.TransactionHistoryQuery) url.Values {
+	values := url.Values{}
+	if query == nil {
+		return values
+	}
+
+	if query.Direction != "" {
+		values.Set("direction", query.Direction)
+	}
+	if query.MinAmount != nil {
+		values.Set("min_amount", query.MinAmount.String())
+	}
+	if query.MaxAmount != nil {
+		values.Set("max_amount", query.MaxAmount.String())
+	}
+	if query.FromTime > 0 {
+		values.Set("from_time", strconv.FormatInt(query.FromTime, 10))
+	}
+	if query.ToTime > 0 {
+		values.Set("to_time", strconv.FormatInt(query.ToTime, 10))
+	}
+	if query.FromBlock != nil {
+		values.Set("from_block", strconv.FormatInt(*query.FromBlock, 10))
+	}
+	if query.ToBlock != nil {
+		values.Set("to_block", strconv.FormatInt(*query.ToBlock, 10))
+	}
+	if query.Order != "" {
+		values.Set("order", query.Order)
+	}
+	if query.Limit > 0 {
+		values.Set("limit", strconv.Itoa(query.Limit))
+	}
+	if query.Cursor != "" {
+		values.Set("cursor", query.Cursor)
+	}
+	if query.IncludePending {
+		values.Set("include_pending", "true")
+	}
+	return values
+}
+
+// ExportTransactionHistory ghi bản xuất lịch sử (format csv hoặc json) vào w
+func (c *Client) ExportTransactionHistory(ctx context.Context, address, format string, w io.Writer) error {
+	values := url.Values{"format": {format}}
+	resp, err := c.request(ctx, http.MethodGet, pathf("/api/transaction/history/%s/export", address), values, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// GetTransaction trả về trạng thái giao dịch: pending, confirmed, dropped hoặc rejected
+func (c *Client) GetTransaction(ctx context.Context, hash string) (*models.TransactionStatusResponse, error) {
+	var response models.TransactionStatusResponse
+	if err := c.do(ctx, http.MethodGet, pathf("/api/transaction/%s", hash), nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// JSON-RPC
+
+// Call gọi method JSON-RPC với params (mảng theo vị trí hoặc object theo tên, nil nếu
+// không có) và giải mã kết quả vào result. Lỗi của method có kiểu *models.RPCError.
+func (c *Client) Call(ctx context.Context, method string, params, result interface{}) error {
+	request := models.RPCRequest{JSONRPC: "2.0", Method: method, ID: json.RawMessage("1")}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		request.Params = data
+	}
+
+	response := models.RPCResponse{Result: result}
+	if err := c.do(ctx, http.MethodPost, "/rpc", nil, request, &response); err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	return nil
+}
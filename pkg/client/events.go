@@ -0,0 +1,121 @@
+package client
+
+import (
+	"MyCoinApp/internal/events"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/websocket"
+)
+
+// maxEventSize là kích thước tối đa của một sự kiện SSE (block lớn nhất cùng giao dịch)
+const maxEventSize = 16 << 20
+
+func eventValues(topics []events.Topic, addresses []string) url.Values {
+	values := url.Values{}
+	if len(topics) > 0 {
+		names := make([]string, len(topics))
+		for i, topic := range topics {
+			names[i] = string(topic)
+		}
+		values.Set("topics", strings.Join(names, ","))
+	}
+	if len(addresses) > 0 {
+		values.Set("addresses", strings.Join(addresses, ","))
+	}
+	return values
+}
+
+// SubscribeEvents nhận sự kiện qua Server-Sent Events và gọi fn cho từng sự kiện cho đến
+// khi ctx bị hủy, fn trả về lỗi hoặc node đóng kết nối. topics và addresses rỗng là tất cả.
+func (c *Client) SubscribeEvents(ctx context.Context, topics []events.Topic, addresses []string, fn func(*events.Event) error) error {
+	resp, err := c.request(ctx, http.MethodGet, "/api/events/sse", eventValues(topics, addresses), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventSize)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		case line == "" && data.Len() > 0:
+			var event events.Event
+			if err := json.Unmarshal([]byte(data.String()), &event); err != nil {
+				return fmt.Errorf("decode event: %w", err)
+			}
+			data.Reset()
+			if err := fn(&event); err != nil {
+				return err
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
+
+// SubscribeEventsWebSocket giống SubscribeEvents nhưng nhận sự kiện qua WebSocket
+func (c *Client) SubscribeEventsWebSocket(ctx context.Context, topics []events.Topic, addresses []string, fn func(*events.Event) error) error {
+	location, err := url.Parse(c.baseURL + "/api/events/ws")
+	if err != nil {
+		return err
+	}
+	origin := *location
+	origin.Path = ""
+	switch location.Scheme {
+	case "https":
+		location.Scheme = "wss"
+	default:
+		location.Scheme = "ws"
+	}
+	location.RawQuery = eventValues(topics, addresses).Encode()
+
+	config, err := websocket.NewConfig(location.String(), origin.String())
+	if err != nil {
+		return err
+	}
+	conn, err := config.DialContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Đóng kết nối khi ctx bị hủy để Receive đang chờ trả về
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		var event events.Event
+		if err := websocket.JSON.Receive(conn, &event); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		// Message giữ kết nối ("{}") không có topic
+		if event.Topic == "" {
+			continue
+		}
+		if err := fn(&event); err != nil {
+			return err
+		}
+	}
+}
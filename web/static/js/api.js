@@ -68,33 +68,47 @@ class MyCoinAPI {
         });
     }
     
-    // Wallet API methods
+    // Wallet API methods: khóa được tạo và giữ trong trình duyệt, node chỉ nhận public key
     async createWallet() {
-        return this.post('/wallet/create', {});
+        const key = await MyCoinSigner.generateKey();
+        const wallet = await this.post('/wallet/import', { public_key: key.publicKey });
+        return { ...wallet, private_key: key.privateKey };
     }
     
     async importWallet(privateKey = null, passphrase = null) {
-        const data = {};
-        if (privateKey) data.private_key = privateKey;
-        if (passphrase) data.passphrase = passphrase;
-        return this.post('/wallet/import', data);
+        if (!privateKey && passphrase) {
+            privateKey = await MyCoinSigner.privateKeyFromPassphrase(passphrase);
+        }
+        const publicKey = MyCoinSigner.publicKeyFromPrivateKey(privateKey);
+        const wallet = await this.post('/wallet/import', { public_key: publicKey });
+        return { ...wallet, private_key: privateKey };
     }
     
     async getBalance(address) {
         return this.get(`/wallet/balance/${address}`);
     }
     
+    async getNonce(address) {
+        return this.get(`/wallet/nonce/${address}`);
+    }
+    
+    // Ký giao dịch ở client với nonce tiếp theo của ví
+    async signTransaction(privateKey, fields) {
+        const { nonce } = await this.getNonce(fields.from);
+        return MyCoinSigner.signTransaction(privateKey, { ...fields, nonce });
+    }
+    
     // Transaction API methods
     async sendTransaction(fromAddress, toAddress, amount, fee, privateKey) {
-        const payload = {
+        const tx = await this.signTransaction(privateKey, {
+            type: 'transfer',
             from: fromAddress,
             to: toAddress,
-            amount: parseFloat(amount),
-            fee: parseFloat(fee),
-            private_key: privateKey
-        };
-        console.log('Sending transaction with payload:', payload);
-        return this.post('/transaction/send', payload);
+            amount: amount,
+            fee: fee
+        });
+        console.log('Broadcasting signed transaction:', tx.hash);
+        return this.post('/transaction/broadcast', tx);
     }
     
     async getTransactionHistory(address) {
@@ -114,26 +128,31 @@ class MyCoinAPI {
         return this.get(`/blockchain/block/${index}`);
     }
     
-    async mineBlock(minerAddress) {
-        return this.post('/blockchain/mine', {
+    // Lấy block mẫu cho validator, ký ở client rồi gửi block đã ký
+    async mineBlock(minerAddress, privateKey) {
+        const template = await this.post('/blockchain/mine/template', {
             miner_address: minerAddress
         });
+        const block = await MyCoinSigner.signBlock(privateKey, template.block);
+        return this.post('/blockchain/mine/submit', block);
     }
     
     // Staking API methods
     async stakeCoins(address, privateKey, amount) {
-        return this.post('/staking/stake', {
-            address: address,
-            private_key: privateKey,
-            amount: parseFloat(amount)
+        const tx = await this.signTransaction(privateKey, {
+            type: 'stake',
+            from: address,
+            amount: amount
         });
+        return this.post('/staking/stake/signed', tx);
     }
     
     async unstakeCoins(address, privateKey) {
-        return this.post('/staking/unstake', {
-            address: address,
-            private_key: privateKey
+        const tx = await this.signTransaction(privateKey, {
+            type: 'unstake',
+            from: address
         });
+        return this.post('/staking/unstake/signed', tx);
     }
     
     async getValidators() {
@@ -3,6 +3,7 @@ class MyCoinApp {
     constructor() {
         this.currentWallet = null;
         this.refreshInterval = null;
+        this.eventSource = null;
         this.currentPage = 1;
         this.itemsPerPage = 4;
         this.totalTransactions = 0;
@@ -520,10 +521,10 @@ class MyCoinApp {
             // Debug: log transaction object to see structure
             console.log('Transaction object:', tx);
             
-            // Fix status logic - if transaction has been retrieved from blockchain.GetTransactionHistory(), 
-            // it means it's already in a block (confirmed). Only pending transactions are in PendingTransactions array
-            const status = 'Đã xác nhận';
-            const statusClass = 'confirmed';
+            // API trả về status = confirmed hoặc pending (khi gọi với include_pending=true)
+            const isPending = tx.status === 'pending';
+            const status = isPending ? 'Đang chờ' : 'Đã xác nhận';
+            const statusClass = isPending ? 'pending' : 'confirmed';
             
             // Determine transaction type and apply color
             const isIncoming = tx.to === currentAddress;
@@ -541,7 +542,7 @@ class MyCoinApp {
             
             row.innerHTML = `
                 <td><code class="hash" title="${tx.hash}">${Utils.formatHash(tx.hash, 12)}</code></td>
-                <td><span class="block-number">Block #${tx.block_index !== undefined ? tx.block_index : 'N/A'}</span></td>
+                <td><span class="block-number">${isPending ? 'Pending' : `Block #${tx.block_index !== undefined ? tx.block_index : 'N/A'}`}</span></td>
                 <td>${timestamp}</td>
                 <td><code class="address" title="${tx.from}">${Utils.formatAddress(tx.from||"genesis")}</code></td>
                 <td><code class="address" title="${tx.to}">${Utils.formatAddress(tx.to)}</code></td>
@@ -671,9 +672,16 @@ class MyCoinApp {
 
         try {
             api.validateAddress(minerAddress);
+
+            // Block được ký trong trình duyệt bằng private key của ví validator đang mở
+            if (!this.currentWallet || this.currentWallet.address !== minerAddress) {
+                Utils.showToast(CONFIG.ERRORS.MINER_WALLET_REQUIRED, 'error');
+                return;
+            }
+
             Utils.showLoading(true);
 
-            const result = await api.mineBlock(minerAddress);
+            const result = await api.mineBlock(minerAddress, this.currentWallet.private_key);
             
             Utils.showToast(CONFIG.SUCCESS.BLOCK_MINED, 'success');
             
@@ -862,21 +870,32 @@ class MyCoinApp {
         }
     }
 
-    // Auto-refresh functionality
+    // Auto-refresh functionality: cập nhật dashboard khi node báo có block mới (SSE),
+    // quay về polling nếu trình duyệt không hỗ trợ EventSource
     startAutoRefresh() {
-        if (this.refreshInterval) {
-            clearInterval(this.refreshInterval);
-        }
+        this.stopAutoRefresh();
 
-        this.refreshInterval = setInterval(() => {
+        const refresh = () => {
             const activeTab = document.querySelector('.tab-content.active').id;
             if (activeTab === 'dashboard') {
                 this.loadDashboard();
             }
-        }, CONFIG.REFRESH_INTERVAL);
+        };
+
+        if (window.EventSource) {
+            this.eventSource = new EventSource(`${CONFIG.API_BASE_URL}/api/events/sse?topics=block`);
+            this.eventSource.addEventListener('block', refresh);
+            return;
+        }
+
+        this.refreshInterval = setInterval(refresh, CONFIG.REFRESH_INTERVAL);
     }
 
     stopAutoRefresh() {
+        if (this.eventSource) {
+            this.eventSource.close();
+            this.eventSource = null;
+        }
         if (this.refreshInterval) {
             clearInterval(this.refreshInterval);
             this.refreshInterval = null;
@@ -38,6 +38,7 @@ const CONFIG = {
         SEND_TRANSACTION_REQUIRED: 'Vui lòng điền đầy đủ thông tin giao dịch',
         NO_WALLET: 'Vui lòng tạo hoặc import ví trước',
         MINER_ADDRESS_REQUIRED: 'Vui lòng nhập địa chỉ miner',
+        MINER_WALLET_REQUIRED: 'Vui lòng mở ví của validator để ký block',
         SEARCH_ADDRESS_REQUIRED: 'Vui lòng nhập địa chỉ để tìm kiếm',
         STAKE_REQUIRED: 'Vui lòng nhập địa chỉ và số lượng để stake',
         UNSTAKE_ADDRESS_REQUIRED: 'Vui lòng nhập địa chỉ để unstake',
@@ -0,0 +1,287 @@
+// Ký giao dịch và block ngay trong trình duyệt. Private key không bao giờ được gửi lên node:
+// node chỉ nhận giao dịch/block đã ký qua /transaction/broadcast, /staking/*/signed
+// và /blockchain/mine/submit. Mã hóa nhị phân giống internal/codec và internal/pool/encoding.go.
+
+class MyCoinSigner {
+    // Phiên bản giao dịch hiện tại (pool.CurrentTxVersion)
+    static TX_VERSION = 3;
+
+    // 1 MYC = 10^8 đơn vị cơ sở (coin.Decimals)
+    static DECIMALS = 8;
+
+    // Tham số đường cong P-256
+    static P = 0xffffffff00000001000000000000000000000000ffffffffffffffffffffffffn;
+    static N = 0xffffffff00000000ffffffffffffffffbce6faada7179e84f3b9cac2fc632551n;
+    static A = MyCoinSigner.P - 3n;
+    static G = {
+        x: 0x6b17d1f2e12c4247f8bce6e563a440f277037d812deb33a0f4a13945d898c296n,
+        y: 0x4fe342e2fe1a7f9b8ee7eb4a7c0f9e162bce33576b315ececbb6406837bf51f5n
+    };
+
+    static subtle() {
+        if (!window.crypto || !window.crypto.subtle) {
+            throw new Error('Trình duyệt không hỗ trợ WebCrypto (cần HTTPS hoặc localhost) để ký giao dịch');
+        }
+        return window.crypto.subtle;
+    }
+
+    // ---- Khóa ----
+
+    // Tạo cặp khóa mới; chỉ public key được gửi lên node để lấy địa chỉ ví
+    static async generateKey() {
+        const keyPair = await this.subtle().generateKey(
+            { name: 'ECDSA', namedCurve: 'P-256' }, true, ['sign', 'verify']);
+        const jwk = await this.subtle().exportKey('jwk', keyPair.privateKey);
+        return {
+            privateKey: this.bytesToHex(this.base64UrlToBytes(jwk.d)),
+            publicKey: this.bytesToHex(this.base64UrlToBytes(jwk.x)) + this.bytesToHex(this.base64UrlToBytes(jwk.y))
+        };
+    }
+
+    // Private key từ passphrase: sha256(passphrase), giống wallet.NewWalletFromPassphrase
+    static async privateKeyFromPassphrase(passphrase) {
+        const digest = await this.subtle().digest('SHA-256', new TextEncoder().encode(passphrase));
+        return this.bytesToHex(new Uint8Array(digest));
+    }
+
+    // Public key X||Y (hex, 64 byte) của private key hex
+    static publicKeyFromPrivateKey(privateKeyHex) {
+        const d = this.parsePrivateKey(privateKeyHex);
+        const point = this.scalarMult(d, this.G);
+        return this.bigIntToHex(point.x, 32) + this.bigIntToHex(point.y, 32);
+    }
+
+    static parsePrivateKey(privateKeyHex) {
+        const hex = (privateKeyHex || '').trim().replace(/^0x/, '');
+        if (!/^[0-9a-fA-F]{1,64}$/.test(hex)) {
+            throw new Error('Private key không hợp lệ');
+        }
+        const d = BigInt('0x' + hex);
+        if (d <= 0n || d >= this.N) {
+            throw new Error('Private key không hợp lệ');
+        }
+        return d;
+    }
+
+    static async importSigningKey(privateKeyHex) {
+        const d = this.parsePrivateKey(privateKeyHex);
+        const publicKey = this.hexToBytes(this.publicKeyFromPrivateKey(privateKeyHex));
+        const jwk = {
+            kty: 'EC',
+            crv: 'P-256',
+            d: this.bytesToBase64Url(this.hexToBytes(this.bigIntToHex(d, 32))),
+            x: this.bytesToBase64Url(publicKey.slice(0, 32)),
+            y: this.bytesToBase64Url(publicKey.slice(32)),
+            ext: false
+        };
+        return this.subtle().importKey('jwk', jwk, { name: 'ECDSA', namedCurve: 'P-256' }, false, ['sign']);
+    }
+
+    // Ký sha256(data), trả về chữ ký r||s dạng hex như wallet.SignHash
+    static async sign(privateKeyHex, data) {
+        const key = await this.importSigningKey(privateKeyHex);
+        const signature = await this.subtle().sign({ name: 'ECDSA', hash: 'SHA-256' }, key, data);
+        return this.bytesToHex(new Uint8Array(signature));
+    }
+
+    static async sha256Hex(data) {
+        return this.bytesToHex(new Uint8Array(await this.subtle().digest('SHA-256', data)));
+    }
+
+    // ---- Giao dịch ----
+
+    // Tạo và ký giao dịch: fields gồm type, from, to, amount, fee, nonce (amount/fee theo MYC)
+    static async signTransaction(privateKeyHex, fields) {
+        const tx = {
+            version: this.TX_VERSION,
+            type: fields.type,
+            from: fields.from,
+            to: fields.to || '',
+            amount: this.formatAmount(this.parseAmount(fields.amount || 0)),
+            fee: this.formatAmount(this.parseAmount(fields.fee || 0)),
+            nonce: Number(fields.nonce),
+            timestamp: Math.floor(Date.now() / 1000)
+        };
+
+        const data = this.encodeTransactionForHash(tx);
+        tx.hash = await this.sha256Hex(data);
+        tx.public_key = this.publicKeyFromPrivateKey(privateKeyHex);
+        tx.signature = await this.sign(privateKeyHex, data);
+        return tx;
+    }
+
+    static encodeTransactionForHash(tx) {
+        const w = new CodecWriter();
+        w.writeUint8(tx.version);
+        w.writeString(tx.type);
+        w.writeString(tx.from);
+        w.writeString(tx.to);
+        w.writeUint64(this.parseAmount(tx.amount));
+        w.writeUint64(this.parseAmount(tx.fee));
+        w.writeUint64(BigInt(tx.nonce));
+        w.writeUint64(BigInt(tx.timestamp));
+        // Giao dịch của người dùng không có phần chia phí và không hết hạn
+        w.writeUint8(0);
+        w.writeUint64(0n);
+        return w.bytes();
+    }
+
+    // ---- Block ----
+
+    // Ký block mẫu nhận từ /blockchain/mine/template; hash được tính lại từ header
+    // để không ký một hash không khớp với nội dung block
+    static async signBlock(privateKeyHex, block) {
+        const w = new CodecWriter();
+        w.writeUint8(block.version);
+        w.writeUint64(BigInt(block.index));
+        w.writeUint64(BigInt(block.timestamp));
+        w.writeString(block.previous_hash);
+        w.writeString(block.validator);
+        w.writeString(block.merkle_root);
+        const header = w.bytes();
+
+        if (await this.sha256Hex(header) !== block.hash) {
+            throw new Error('Block mẫu có hash không khớp với header');
+        }
+
+        return {
+            ...block,
+            validator_public_key: this.publicKeyFromPrivateKey(privateKeyHex),
+            signature: await this.sign(privateKeyHex, header)
+        };
+    }
+
+    // ---- Số lượng coin ----
+
+    // Đổi số MYC (số hoặc chuỗi thập phân) sang đơn vị cơ sở, như coin.ParseAmount
+    static parseAmount(value) {
+        let s = typeof value === 'number' ? value.toFixed(this.DECIMALS) : String(value).trim();
+        const match = /^(\d+)(?:\.(\d*))?$/.exec(s);
+        if (!match) {
+            throw new Error(CONFIG.ERRORS.INVALID_AMOUNT);
+        }
+        const fraction = (match[2] || '').replace(/0+$/, '');
+        if (fraction.length > this.DECIMALS) {
+            throw new Error(CONFIG.ERRORS.INVALID_AMOUNT);
+        }
+        return BigInt(match[1]) * 10n ** BigInt(this.DECIMALS) + BigInt(fraction.padEnd(this.DECIMALS, '0') || '0');
+    }
+
+    static formatAmount(units) {
+        const unit = 10n ** BigInt(this.DECIMALS);
+        const fraction = (units % unit).toString().padStart(this.DECIMALS, '0').replace(/0+$/, '');
+        return fraction ? `${units / unit}.${fraction}` : `${units / unit}`;
+    }
+
+    // ---- Số học trên P-256 (chỉ dùng để suy ra public key từ private key) ----
+
+    static mod(a, m = this.P) {
+        const r = a % m;
+        return r >= 0n ? r : r + m;
+    }
+
+    static modInverse(a, m = this.P) {
+        let [oldR, r] = [this.mod(a, m), m];
+        let [oldS, s] = [1n, 0n];
+        while (r !== 0n) {
+            const q = oldR / r;
+            [oldR, r] = [r, oldR - q * r];
+            [oldS, s] = [s, oldS - q * s];
+        }
+        return this.mod(oldS, m);
+    }
+
+    static pointAdd(p1, p2) {
+        if (p1 === null) return p2;
+        if (p2 === null) return p1;
+        if (p1.x === p2.x) {
+            if (this.mod(p1.y + p2.y) === 0n) return null;
+            return this.pointDouble(p1);
+        }
+        const lambda = this.mod((p2.y - p1.y) * this.modInverse(p2.x - p1.x));
+        const x = this.mod(lambda * lambda - p1.x - p2.x);
+        return { x, y: this.mod(lambda * (p1.x - x) - p1.y) };
+    }
+
+    static pointDouble(point) {
+        const lambda = this.mod((3n * point.x * point.x + this.A) * this.modInverse(2n * point.y));
+        const x = this.mod(lambda * lambda - 2n * point.x);
+        return { x, y: this.mod(lambda * (point.x - x) - point.y) };
+    }
+
+    static scalarMult(k, point) {
+        let result = null;
+        let addend = point;
+        while (k > 0n) {
+            if (k & 1n) result = this.pointAdd(result, addend);
+            addend = this.pointDouble(addend);
+            k >>= 1n;
+        }
+        return result;
+    }
+
+    // ---- Chuyển đổi ----
+
+    static bytesToHex(bytes) {
+        return Array.from(bytes, b => b.toString(16).padStart(2, '0')).join('');
+    }
+
+    static hexToBytes(hex) {
+        const bytes = new Uint8Array(hex.length / 2);
+        for (let i = 0; i < bytes.length; i++) {
+            bytes[i] = parseInt(hex.substr(i * 2, 2), 16);
+        }
+        return bytes;
+    }
+
+    static bigIntToHex(value, size) {
+        return value.toString(16).padStart(size * 2, '0');
+    }
+
+    static base64UrlToBytes(value) {
+        const base64 = value.replace(/-/g, '+').replace(/_/g, '/');
+        return Uint8Array.from(atob(base64.padEnd(Math.ceil(base64.length / 4) * 4, '=')), c => c.charCodeAt(0));
+    }
+
+    static bytesToBase64Url(bytes) {
+        return btoa(String.fromCharCode(...bytes)).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '');
+    }
+}
+
+// CodecWriter ghi dữ liệu giống codec.Writer: số nguyên big-endian cố định,
+// chuỗi có tiền tố độ dài uvarint
+class CodecWriter {
+    constructor() {
+        this.parts = [];
+    }
+
+    writeUint8(value) {
+        this.parts.push(value & 0xff);
+    }
+
+    writeUint64(value) {
+        const v = BigInt.asUintN(64, BigInt(value));
+        for (let shift = 56n; shift >= 0n; shift -= 8n) {
+            this.parts.push(Number((v >> shift) & 0xffn));
+        }
+    }
+
+    writeUvarint(value) {
+        let v = BigInt(value);
+        while (v >= 0x80n) {
+            this.parts.push(Number(v & 0x7fn) | 0x80);
+            v >>= 7n;
+        }
+        this.parts.push(Number(v));
+    }
+
+    writeString(value) {
+        const bytes = new TextEncoder().encode(value);
+        this.writeUvarint(bytes.length);
+        this.parts.push(...bytes);
+    }
+
+    bytes() {
+        return Uint8Array.from(this.parts);
+    }
+}